@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/achievements"
+	"github.com/nppe-pro/api/internal/audit"
+	"github.com/nppe-pro/api/internal/dashboard"
+	"github.com/nppe-pro/api/internal/deletion"
+	"github.com/nppe-pro/api/internal/email"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/exam"
+	"github.com/nppe-pro/api/internal/export"
+	"github.com/nppe-pro/api/internal/jobs"
+	"github.com/nppe-pro/api/internal/mfa"
+	"github.com/nppe-pro/api/internal/rbac"
+	"github.com/nppe-pro/api/internal/streak"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/jwt"
+	"github.com/nppe-pro/api/pkg/storage"
+	"github.com/nppe-pro/api/pkg/tokens"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+// run starts the server and its background jobs and blocks until a signal
+// shuts them down. Errors are returned rather than fatal so every deferred
+// close runs before main exits.
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	migrate := flag.Bool("migrate", cfg.Server.AutoMigrate, "enable extensions, run auto-migrations and create indexes on startup")
+	flag.Parse()
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.Server.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *migrate {
+		if err := runMigrations(db, cfg); err != nil {
+			return err
+		}
+	}
+
+	redisClient, err := database.NewRedisClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer redisClient.Close()
+
+	jwtService, err := jwt.NewJWTService(cfg)
+	if err != nil {
+		return err
+	}
+
+	mailer, err := email.NewSender(cfg)
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Domain events connect the services that produce them (tests, answers)
+	// to the ones that react (streaks, achievements, cached dashboards)
+	bus := events.NewBus()
+	streaks := streak.NewService(db.DB, cfg, bus)
+	streaks.Subscribe(bus)
+	achievements.NewService(db.DB).Subscribe(bus)
+	dashboard.NewService(db.DB, redisClient, cfg, streaks).Subscribe(bus)
+
+	router, err := newRouter(cfg, db, redisClient, jwtService, store, bus)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 %s listening on %s (%s)", cfg.Server.AppName, srv.Addr, cfg.Server.Environment)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("server error: %w", err)
+			stop()
+		}
+	}()
+
+	// Background jobs stop with the server
+	runner := jobs.NewRunner(redisClient)
+	var running sync.WaitGroup
+	startJob := func(job jobs.Job) {
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			runner.Start(ctx, job)
+		}()
+	}
+	startJob(exam.NewSweeper(exam.NewService(db.DB, cfg, bus)).Job())
+	startJob(streak.NewSweeper(streaks).Job())
+	startJob(email.NewDispatcher(db.DB, mailer, cfg).Job())
+	deletions := deletion.NewService(db.DB, tokens.NewStore(redisClient, cfg), store, cfg)
+	startJob(deletion.NewSweeper(deletions).Job())
+	startJob(export.NewWorker(export.NewService(db.DB, store, cfg)).Job())
+
+	<-ctx.Done()
+	stop()
+	log.Println("🛑 Shutting down, draining in-flight requests...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	shutdownErr := srv.Shutdown(shutdownCtx)
+
+	// The jobs saw ctx cancelled; wait for the runs in flight so none of them
+	// still uses the database or Redis when the deferred closes run
+	jobsDone := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		log.Printf("⚠️ Background jobs still running after %s", cfg.Server.ShutdownTimeout)
+	}
+
+	if shutdownErr != nil {
+		return fmt.Errorf("forced shutdown: %w", shutdownErr)
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	default:
+	}
+
+	log.Println("✅ Server stopped")
+	return nil
+}
+
+// runMigrations prepares the schema: extensions first (indexes depend on
+// pg_trgm), then tables, then the additional indexes, triggers and seed data,
+// and finally the encryption of MFA secrets stored in the clear.
+func runMigrations(db *database.Database, cfg *config.Config) error {
+	if err := db.EnableExtensions(); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(); err != nil {
+		return err
+	}
+	if err := db.CreateIndexes(); err != nil {
+		return err
+	}
+	if err := audit.Protect(db.DB); err != nil {
+		return err
+	}
+	if err := achievements.Seed(db.DB); err != nil {
+		return err
+	}
+	if err := rbac.Seed(db.DB); err != nil {
+		return err
+	}
+	return mfa.SealSecrets(db.DB, cfg)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/handlers"
+	"github.com/nppe-pro/api/internal/rbac"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/jwt"
+	"github.com/nppe-pro/api/pkg/middleware"
+	"github.com/nppe-pro/api/pkg/storage"
+	"github.com/nppe-pro/api/pkg/tokens"
+)
+
+// newRouter builds the gin engine and registers every handler under /api/v1
+func newRouter(cfg *config.Config, db *database.Database, redisClient *database.RedisClient, jwtService *jwt.JWTService, store storage.Store, bus *events.Bus) (*gin.Engine, error) {
+	router := gin.New()
+	// Without trusted proxies ClientIP is the peer address, so per-IP rate
+	// limits and lockouts cannot be dodged with a forged X-Forwarded-For
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	router.Use(middleware.RequestID(), gin.Logger(), gin.Recovery())
+	router.Use(middleware.CORSMiddleware(cfg))
+
+	tokenStore := tokens.NewStore(redisClient, cfg)
+
+	authHandler := handlers.NewAuthHandler(db.DB, redisClient, jwtService, tokenStore, store, cfg)
+	userHandler := handlers.NewUserHandler(db.DB, redisClient, tokenStore, store, cfg, bus)
+	questionHandler := handlers.NewQuestionHandler(db.DB, redisClient, cfg, bus)
+	testHandler := handlers.NewTestHandler(db.DB, redisClient, cfg, bus)
+	dashboardHandler := handlers.NewDashboardHandler(db.DB, redisClient, cfg, bus)
+	achievementHandler := handlers.NewAchievementHandler(db.DB, redisClient)
+	mfaHandler := handlers.NewMFAHandler(db.DB, redisClient, cfg)
+	roleHandler := handlers.NewRoleHandler(db.DB, redisClient, tokenStore)
+	auditHandler := handlers.NewAuditHandler(db.DB)
+	exportHandler := handlers.NewExportHandler(db.DB, store, cfg)
+
+	authRequired := middleware.AuthMiddleware(jwtService, tokenStore)
+	authOptional := middleware.OptionalAuthMiddleware(jwtService, tokenStore)
+
+	// Rate limits count per user after authRequired, otherwise per IP
+	limiter := middleware.NewRateLimiter(redisClient, cfg)
+	defaultLimit := limiter.Limit("default", cfg.RateLimit.Default())
+	authLimit := limiter.Limit("auth", cfg.RateLimit.Auth)
+	answerLimit := limiter.Limit("answers", cfg.RateLimit.Answers)
+
+	router.GET("/health", healthCheck(db, redisClient))
+	router.GET("/.well-known/jwks.json", jwks(jwtService))
+
+	// Signed links to local uploads; S3 serves its own
+	if local, ok := store.(*storage.Local); ok {
+		router.GET(storage.LocalPrefix+"*key", gin.WrapH(http.StripPrefix(strings.TrimSuffix(storage.LocalPrefix, "/"), local)))
+	}
+
+	v1 := router.Group("/api/v1")
+
+	// Authentication
+	auth := v1.Group("/auth", defaultLimit)
+	{
+		auth.POST("/register", authLimit, authHandler.Register)
+		auth.POST("/login", authLimit, authHandler.Login)
+		auth.POST("/mfa/enroll", authLimit, authHandler.EnrollMFA)
+		auth.POST("/mfa/verify", authLimit, authHandler.VerifyMFA)
+		auth.POST("/refresh", authLimit, authHandler.RefreshToken)
+		auth.POST("/forgot-password", authLimit, authHandler.ForgotPassword)
+		auth.POST("/reset-password", authLimit, authHandler.ResetPassword)
+		auth.GET("/verify/:token", authHandler.VerifyEmail)
+		auth.GET("/google", authHandler.GoogleLogin)
+		auth.GET("/google/callback", authHandler.GoogleCallback)
+		auth.POST("/logout", authRequired, authHandler.Logout)
+		auth.POST("/logout-all", authRequired, authHandler.LogoutAll)
+		auth.GET("/me", authRequired, authHandler.GetCurrentUser)
+	}
+
+	// Users
+	users := v1.Group("/users", authRequired, defaultLimit)
+	{
+		users.GET("/me", userHandler.GetProfile)
+		users.PUT("/me", userHandler.UpdateProfile)
+		users.DELETE("/me", userHandler.DeleteAccount)
+		users.POST("/me/restore", userHandler.RestoreAccount)
+		users.POST("/me/exports", exportHandler.RequestExport)
+		users.GET("/me/exports", exportHandler.ListExports)
+		users.GET("/me/exports/:id", exportHandler.GetExport)
+		users.POST("/me/avatar", userHandler.UploadAvatar)
+		users.GET("/me/bookmarks", userHandler.GetBookmarks)
+		users.PUT("/me/bookmarks/:question_id", userHandler.UpdateBookmark)
+		users.GET("/me/bookmark-folders", userHandler.GetBookmarkFolders)
+		users.POST("/me/bookmark-folders", userHandler.CreateBookmarkFolder)
+		users.PUT("/me/bookmark-folders/:id", userHandler.RenameBookmarkFolder)
+		users.DELETE("/me/bookmark-folders/:id", userHandler.DeleteBookmarkFolder)
+		users.GET("/me/practice-tests", testHandler.GetTestHistory)
+		users.GET("/me/practice-tests/summary", testHandler.GetTestHistorySummary)
+		users.GET("/me/study-path", userHandler.GetStudyPath)
+		users.PUT("/me/modules/:id/progress", userHandler.UpdateModuleProgress)
+		users.GET("/me/dashboard", dashboardHandler.GetDashboard)
+		users.GET("/me/analytics", dashboardHandler.GetAnalytics)
+		users.GET("/me/weaknesses", dashboardHandler.GetWeaknesses)
+		users.GET("/me/achievements", achievementHandler.GetAchievements)
+		users.GET("/me/achievements/progress", achievementHandler.GetAchievementProgress)
+		users.GET("/me/sessions", authHandler.ListSessions)
+		users.DELETE("/me/sessions/:id", authHandler.RevokeSession)
+		users.GET("/me/mfa", mfaHandler.GetMFAStatus)
+		users.POST("/me/mfa/enroll", mfaHandler.EnrollMFA)
+		users.POST("/me/mfa/activate", mfaHandler.ActivateMFA)
+		users.POST("/me/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+		users.DELETE("/me/mfa", mfaHandler.DisableMFA)
+		users.PUT("/me/notification-settings", userHandler.UpdateNotificationSettings)
+	}
+
+	// Questions
+	questions := v1.Group("/questions", defaultLimit)
+	{
+		questions.GET("", authOptional, questionHandler.GetQuestions)
+		questions.GET("/:id", authOptional, questionHandler.GetQuestion)
+		questions.POST("/:id/answer", authRequired, answerLimit, questionHandler.SubmitAnswer)
+		questions.POST("/:id/bookmark", authRequired, questionHandler.BookmarkQuestion)
+		questions.DELETE("/:id/bookmark", authRequired, questionHandler.RemoveBookmark)
+	}
+
+	// Topics
+	topics := v1.Group("/topics", defaultLimit)
+	{
+		topics.GET("", questionHandler.GetTopics)
+		topics.GET("/:id", questionHandler.GetTopic)
+	}
+
+	// Practice tests
+	tests := v1.Group("/practice-tests", authRequired, defaultLimit)
+	{
+		tests.POST("", testHandler.StartTest)
+		tests.GET("/:id", testHandler.GetTest)
+		tests.POST("/:id/questions/:position/answer", answerLimit, testHandler.SubmitTestAnswer)
+		tests.POST("/:id/complete", testHandler.CompleteTest)
+		tests.GET("/:id/review", testHandler.ReviewTest)
+		tests.GET("/:id/results", testHandler.GetTestResults)
+	}
+
+	// Subscriptions
+	subscriptions := v1.Group("/subscriptions", authRequired, defaultLimit)
+	{
+		subscriptions.POST("", userHandler.CreateSubscription)
+		subscriptions.GET("/current", userHandler.GetSubscription)
+		subscriptions.DELETE("/current", userHandler.CancelSubscription)
+	}
+
+	// Notifications
+	notifications := v1.Group("/notifications", authRequired, defaultLimit)
+	{
+		notifications.GET("", userHandler.GetNotifications)
+		notifications.PUT("/:id/read", userHandler.MarkNotificationRead)
+	}
+
+	// Admin: each route requires its own permission
+	can := middleware.RequirePermission
+	admin := v1.Group("/admin", authRequired, defaultLimit)
+	{
+		admin.GET("/users", can(rbac.PermUsersRead), userHandler.ListUsers)
+		admin.POST("/users/:id/unlock", can(rbac.PermUsersUnlock), authHandler.UnlockUser)
+		admin.GET("/users/:id/roles", can(rbac.PermRolesManage), roleHandler.GetUserRoles)
+		admin.PUT("/users/:id/roles", can(rbac.PermRolesManage), roleHandler.SetUserRoles)
+		admin.GET("/roles", can(rbac.PermRolesManage), roleHandler.ListRoles)
+		admin.GET("/statistics", can(rbac.PermStatisticsRead), dashboardHandler.GetAdminStatistics)
+		admin.GET("/audit-logs", can(rbac.PermAuditRead), auditHandler.ListAuditLogs)
+		admin.GET("/audit-logs/export", can(rbac.PermAuditRead), auditHandler.ExportAuditLogs)
+
+		admin.GET("/questions", can(rbac.PermQuestionsRead), questionHandler.AdminListQuestions)
+		admin.GET("/questions/:id", can(rbac.PermQuestionsRead), questionHandler.AdminGetQuestion)
+		admin.POST("/questions", can(rbac.PermQuestionsWrite), questionHandler.CreateQuestion)
+		admin.PUT("/questions/:id", can(rbac.PermQuestionsWrite), questionHandler.UpdateQuestion)
+		admin.DELETE("/questions/:id", can(rbac.PermQuestionsDelete), questionHandler.DeleteQuestion)
+		admin.POST("/questions/bulk", can(rbac.PermQuestionsPublish), limiter.Limit("admin_bulk", cfg.RateLimit.AdminBulk), questionHandler.BulkOperations)
+	}
+
+	// Webhooks
+	webhooks := v1.Group("/webhooks")
+	{
+		webhooks.POST("/stripe", userHandler.StripeWebhook)
+	}
+
+	return router, nil
+}
+
+// healthCheck reports whether the API can reach PostgreSQL and Redis
+func healthCheck(db *database.Database, redisClient *database.RedisClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		status := http.StatusOK
+		checks := gin.H{"database": "ok", "redis": "ok"}
+
+		if sqlDB, err := db.DB.DB(); err != nil || sqlDB.PingContext(ctx) != nil {
+			status = http.StatusServiceUnavailable
+			checks["database"] = "unavailable"
+		}
+
+		if err := redisClient.Client.Ping(ctx).Err(); err != nil {
+			status = http.StatusServiceUnavailable
+			checks["redis"] = "unavailable"
+		}
+
+		c.JSON(status, gin.H{
+			"status": http.StatusText(status),
+			"checks": checks,
+		})
+	}
+}
+
+// jwks publishes the public keys that verify access tokens, so other
+// services can validate them without sharing a secret
+func jwks(jwtService *jwt.JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwtService.JWKS())
+	}
+}
@@ -0,0 +1,64 @@
+package achievements
+
+import (
+	"fmt"
+
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultAchievements are seeded on migration. Existing rows are left
+// untouched so definitions can be tuned in the database.
+var DefaultAchievements = []models.Achievement{
+	{Code: "first_steps", Name: "First Steps", Description: "Answered your first practice question", Category: "study", Icon: "ri-footprint-line", Rarity: "common", Points: 10, Metric: "questions_answered", Threshold: 1},
+	{Code: "question_master", Name: "Question Master", Description: "Answered 100 questions correctly", Category: "study", Icon: "ri-question-mark", Rarity: "uncommon", Points: 50, Metric: "questions_correct", Threshold: 100},
+	{Code: "excellence_award", Name: "Excellence Award", Description: "Scored 90% or higher on a practice test", Category: "practice", Icon: "trophy", Rarity: "rare", Points: 100, Metric: "best_test_score", Threshold: 90},
+	{Code: "perfect_score", Name: "Perfect Score", Description: "Achieved 100% on a practice test", Category: "practice", Icon: "star", Rarity: "legendary", Points: 250, Metric: "best_test_score", Threshold: 100},
+	{Code: "speed_master", Name: "Speed Master", Description: "Completed a test of 10 or more questions in under 60 minutes", Category: "practice", Icon: "speed", Rarity: "common", Points: 50, Metric: "tests_under_an_hour", Threshold: 1},
+	{Code: "consistency_champion", Name: "Consistency Champion", Description: "Scored 80% or higher on three practice tests", Category: "practice", Icon: "medal", Rarity: "rare", Points: 100, Metric: "tests_scored_80", Threshold: 3},
+	{Code: "topic_perfectionist", Name: "Topic Perfectionist", Description: "Answered every question of a topic correctly in a practice test", Category: "practice", Icon: "star", Rarity: "epic", Points: 150, Metric: "perfect_topic_results", Threshold: 1},
+	{Code: "topic_master", Name: "Topic Master", Description: "Mastered your first topic", Category: "mastery", Icon: "ri-medal-line", Rarity: "uncommon", Points: 75, Metric: "topics_mastered", Threshold: 1},
+	{Code: "syllabus_master", Name: "Syllabus Master", Description: "Mastered five topics", Category: "mastery", Icon: "ri-book-open-line", Rarity: "epic", Points: 200, Metric: "topics_mastered", Threshold: 5},
+}
+
+// DefaultBadges are seeded on migration alongside DefaultAchievements
+var DefaultBadges = []models.Badge{
+	{Code: "test_taker_1", Family: "test_taker", Name: "Test Taker I", Description: "Completed a practice test", Category: "practice", Icon: "ri-file-list-line", Level: 1, Metric: "tests_completed", Threshold: 1},
+	{Code: "test_taker_2", Family: "test_taker", Name: "Test Taker II", Description: "Completed 10 practice tests", Category: "practice", Icon: "ri-file-list-line", Level: 2, Metric: "tests_completed", Threshold: 10},
+	{Code: "test_taker_3", Family: "test_taker", Name: "Test Taker III", Description: "Completed 25 practice tests", Category: "practice", Icon: "ri-file-list-line", Level: 3, Metric: "tests_completed", Threshold: 25},
+	{Code: "question_solver_1", Family: "question_solver", Name: "Question Solver I", Description: "Answered 100 questions", Category: "study", Icon: "ri-question-line", Level: 1, Metric: "questions_answered", Threshold: 100},
+	{Code: "question_solver_2", Family: "question_solver", Name: "Question Solver II", Description: "Answered 500 questions", Category: "study", Icon: "ri-question-line", Level: 2, Metric: "questions_answered", Threshold: 500},
+	{Code: "question_solver_3", Family: "question_solver", Name: "Question Solver III", Description: "Answered 1,000 questions", Category: "study", Icon: "ri-question-line", Level: 3, Metric: "questions_answered", Threshold: 1000},
+	{Code: "streak_keeper_1", Family: "streak_keeper", Name: "Streak Keeper I", Description: "Studied 7 days in a row", Category: "study", Icon: "ri-fire-line", Level: 1, Metric: "longest_streak", Threshold: 7},
+	{Code: "streak_keeper_2", Family: "streak_keeper", Name: "Streak Keeper II", Description: "Studied 30 days in a row", Category: "study", Icon: "ri-fire-line", Level: 2, Metric: "longest_streak", Threshold: 30},
+	{Code: "streak_keeper_3", Family: "streak_keeper", Name: "Streak Keeper III", Description: "Studied 100 days in a row", Category: "study", Icon: "ri-fire-line", Level: 3, Metric: "longest_streak", Threshold: 100},
+}
+
+// Seed inserts the default definitions that do not exist yet
+func Seed(db *gorm.DB) error {
+	for _, def := range DefaultAchievements {
+		if _, ok := metrics[def.Metric]; !ok {
+			return fmt.Errorf("achievement %s uses unknown metric %s", def.Code, def.Metric)
+		}
+	}
+	for _, def := range DefaultBadges {
+		if _, ok := metrics[def.Metric]; !ok {
+			return fmt.Errorf("badge %s uses unknown metric %s", def.Code, def.Metric)
+		}
+	}
+
+	onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}
+
+	achievements := append([]models.Achievement(nil), DefaultAchievements...)
+	if err := db.Clauses(onConflict).Create(&achievements).Error; err != nil {
+		return fmt.Errorf("failed to seed achievements: %w", err)
+	}
+
+	badges := append([]models.Badge(nil), DefaultBadges...)
+	if err := db.Clauses(onConflict).Create(&badges).Error; err != nil {
+		return fmt.Errorf("failed to seed badges: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package achievements
+
+import (
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/mastery"
+	"gorm.io/gorm"
+)
+
+// metric is a per-user quantity that definitions set thresholds on
+type metric struct {
+	// events that can move the metric, and therefore trigger evaluation
+	events []events.Type
+	// requirement describes the threshold for the progress page
+	requirement string
+	value       func(db *gorm.DB, userID uuid.UUID) (float64, error)
+}
+
+var metrics = map[string]metric{
+	"questions_answered": {
+		events:      []events.Type{events.AnswerRecorded, events.TestCompleted},
+		requirement: "Answer %v practice questions",
+		value:       statsColumn("questions_completed"),
+	},
+	"questions_correct": {
+		events:      []events.Type{events.AnswerRecorded, events.TestCompleted},
+		requirement: "Answer %v questions correctly",
+		value:       statsColumn("questions_correct"),
+	},
+	"tests_completed": {
+		events:      []events.Type{events.TestCompleted},
+		requirement: "Complete %v practice tests",
+		value: func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+			return scalar(db.Table("practice_tests").
+				Where("user_id = ? AND status = ?", userID, "completed").
+				Select("COUNT(*)"))
+		},
+	},
+	"best_test_score": {
+		events:      []events.Type{events.TestCompleted},
+		requirement: "Score %v%% on a practice test",
+		value: func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+			return scalar(db.Table("practice_tests").
+				Where("user_id = ? AND status = ?", userID, "completed").
+				Select("COALESCE(MAX(score), 0)"))
+		},
+	},
+	"tests_scored_80": {
+		events:      []events.Type{events.TestCompleted},
+		requirement: "Score 80% or higher on %v practice tests",
+		value: func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+			return scalar(db.Table("practice_tests").
+				Where("user_id = ? AND status = ? AND score >= 80", userID, "completed").
+				Select("COUNT(*)"))
+		},
+	},
+	"tests_under_an_hour": {
+		events:      []events.Type{events.TestCompleted},
+		requirement: "Finish %v tests of 10+ questions in under an hour",
+		value: func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+			return scalar(db.Table("practice_tests").
+				Where("user_id = ? AND status = ? AND auto_submitted = ?", userID, "completed", false).
+				Where("total_questions >= 10 AND time_spent_seconds < 3600").
+				Select("COUNT(*)"))
+		},
+	},
+	"perfect_topic_results": {
+		events:      []events.Type{events.TestCompleted},
+		requirement: "Answer every question of a topic correctly in a test, %v time(s)",
+		value: func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+			// Topics with at least five questions in a completed test, all correct
+			perfect := db.Table("practice_test_questions AS ptq").
+				Joins("JOIN practice_tests pt ON pt.id = ptq.practice_test_id").
+				Joins("JOIN questions q ON q.id = ptq.question_id").
+				Where("pt.user_id = ? AND pt.status = ?", userID, "completed").
+				Group("ptq.practice_test_id, q.topic_id").
+				Having("COUNT(*) >= 5 AND BOOL_AND(COALESCE(ptq.is_correct, FALSE))").
+				Select("1")
+			return scalar(db.Table("(?) AS perfect", perfect).Select("COUNT(*)"))
+		},
+	},
+	"topics_mastered": {
+		events:      []events.Type{events.TopicMastered},
+		requirement: "Master %v topics",
+		value: func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+			return scalar(db.Table("user_topic_masteries").
+				Where("user_id = ?", userID).
+				Where("questions_attempted >= ? AND mastery_percentage >= ?",
+					mastery.MasteredMinAttempts, mastery.MasteredPercentage).
+				Select("COUNT(*)"))
+		},
+	},
+	"longest_streak": {
+		events:      []events.Type{events.StreakExtended},
+		requirement: "Study %v days in a row",
+		value: func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+			return scalar(db.Table("users").
+				Where("id = ?", userID).
+				Select("COALESCE(longest_streak, 0)"))
+		},
+	},
+}
+
+func statsColumn(column string) func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+	return func(db *gorm.DB, userID uuid.UUID) (float64, error) {
+		return scalar(db.Table("user_stats").
+			Where("user_id = ?", userID).
+			Select("COALESCE(MAX(" + column + "), 0)"))
+	}
+}
+
+func scalar(query *gorm.DB) (float64, error) {
+	var v float64
+	err := query.Scan(&v).Error
+	return v, err
+}
+
+// metricsFor lists the metrics an event can move
+func metricsFor(t events.Type) []string {
+	names := make([]string, 0)
+	for name, m := range metrics {
+		for _, et := range m.events {
+			if et == t {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,381 @@
+// Package achievements awards persistent achievements and badges.
+//
+// Definitions live in the achievements and badges tables. Each names a
+// metric (see metrics.go) and a threshold; when a domain event that can
+// move the metric is published, the definitions the user has not earned
+// yet are evaluated and the ones that reach their threshold are recorded in
+// user_achievements exactly once.
+package achievements
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Service evaluates and lists achievements
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new achievements service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Definition is an achievement or badge definition in a common shape
+type Definition struct {
+	ID          uuid.UUID
+	Kind        string
+	Code        string
+	Family      string
+	Name        string
+	Description string
+	Category    string
+	Icon        string
+	Rarity      string
+	Points      int
+	Level       int
+	Metric      string
+	Threshold   float64
+}
+
+// Award is a definition the user has earned
+type Award struct {
+	Definition
+	EarnedAt   time.Time
+	SourceType string
+	SourceID   *uuid.UUID
+}
+
+// Progress is a definition with the user's current standing
+type Progress struct {
+	Definition
+	Current     float64
+	Percentage  float64
+	Requirement string
+	Unlocked    bool
+	UnlockedAt  *time.Time
+}
+
+// Subscribe evaluates achievements whenever an event that can move one of
+// the metrics is published on bus
+func (s *Service) Subscribe(bus *events.Bus) {
+	seen := make(map[events.Type]bool)
+	for _, m := range metrics {
+		for _, t := range m.events {
+			if !seen[t] {
+				seen[t] = true
+				bus.Subscribe(t, func(ctx context.Context, e events.Event) error {
+					_, err := s.Evaluate(ctx, e)
+					return err
+				})
+			}
+		}
+	}
+}
+
+// Evaluate awards every unearned definition whose metric e can move and
+// that now meets its threshold. It returns the newly earned awards.
+func (s *Service) Evaluate(ctx context.Context, e events.Event) ([]Award, error) {
+	names := metricsFor(e.Type)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	db := s.db.WithContext(ctx)
+	defs, err := s.definitions(db, "metric IN ?", names)
+	if err != nil {
+		return nil, err
+	}
+
+	earned, err := s.earnedIDs(db, e.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64)
+	sourceType, sourceID := source(e)
+	awarded := make([]Award, 0)
+
+	for _, def := range defs {
+		if earned[def.ID] {
+			continue
+		}
+
+		m, ok := metrics[def.Metric]
+		if !ok {
+			continue
+		}
+
+		value, ok := values[def.Metric]
+		if !ok {
+			value, err = m.value(db, e.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute metric %s: %w", def.Metric, err)
+			}
+			values[def.Metric] = value
+		}
+		if value < def.Threshold {
+			continue
+		}
+
+		award := models.UserAchievement{
+			UserID:       e.UserID,
+			Kind:         def.Kind,
+			DefinitionID: def.ID,
+			SourceType:   sourceType,
+			SourceID:     sourceID,
+			EarnedAt:     e.OccurredAt,
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&award)
+			if result.Error != nil {
+				return result.Error
+			}
+			// Another request got there first
+			if result.RowsAffected == 0 {
+				return nil
+			}
+
+			awarded = append(awarded, Award{
+				Definition: def,
+				EarnedAt:   award.EarnedAt,
+				SourceType: sourceType,
+				SourceID:   sourceID,
+			})
+
+			return tx.Create(&models.Notification{
+				UserID:  e.UserID,
+				Type:    "achievement",
+				Title:   "New " + def.Kind + " unlocked: " + def.Name,
+				Message: def.Description,
+				Link:    "/achievements",
+			}).Error
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to award %s: %w", def.Code, err)
+		}
+	}
+
+	return awarded, nil
+}
+
+// Earned lists the user's awards, most recent first
+func (s *Service) Earned(ctx context.Context, userID uuid.UUID) ([]Award, error) {
+	return s.awards(s.db.WithContext(ctx), "user_id = ?", userID)
+}
+
+// EarnedFrom lists the awards a single source, such as a practice test,
+// unlocked
+func (s *Service) EarnedFrom(ctx context.Context, userID uuid.UUID, sourceType string, sourceID uuid.UUID) ([]Award, error) {
+	return s.awards(s.db.WithContext(ctx),
+		"user_id = ? AND source_type = ? AND source_id = ?", userID, sourceType, sourceID)
+}
+
+// Progress reports the user's standing on every active definition
+func (s *Service) Progress(ctx context.Context, userID uuid.UUID) ([]Progress, error) {
+	db := s.db.WithContext(ctx)
+
+	defs, err := s.definitions(db, "")
+	if err != nil {
+		return nil, err
+	}
+
+	awards, err := s.awards(db, "user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	earnedAt := make(map[uuid.UUID]time.Time, len(awards))
+	for _, a := range awards {
+		earnedAt[a.ID] = a.EarnedAt
+	}
+
+	values := make(map[string]float64)
+	progress := make([]Progress, 0, len(defs))
+
+	for _, def := range defs {
+		m, ok := metrics[def.Metric]
+		if !ok {
+			continue
+		}
+
+		value, ok := values[def.Metric]
+		if !ok {
+			value, err = m.value(db, userID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute metric %s: %w", def.Metric, err)
+			}
+			values[def.Metric] = value
+		}
+
+		p := Progress{
+			Definition:  def,
+			Current:     value,
+			Requirement: fmt.Sprintf(m.requirement, def.Threshold),
+		}
+		if at, ok := earnedAt[def.ID]; ok {
+			at := at
+			p.Unlocked = true
+			p.UnlockedAt = &at
+			p.Percentage = 100
+		} else if def.Threshold > 0 {
+			p.Percentage = value / def.Threshold * 100
+			if p.Percentage > 100 {
+				p.Percentage = 100
+			}
+		}
+
+		progress = append(progress, p)
+	}
+
+	return progress, nil
+}
+
+// definitions loads active achievements and badges, optionally filtered
+func (s *Service) definitions(db *gorm.DB, where string, args ...interface{}) ([]Definition, error) {
+	return loadDefinitions(db, func(q *gorm.DB) *gorm.DB {
+		q = q.Where("is_active = ?", true)
+		if where != "" {
+			q = q.Where(where, args...)
+		}
+		return q
+	})
+}
+
+// loadDefinitions loads achievements then badges matching scope, each in
+// display order
+func loadDefinitions(db *gorm.DB, scope func(*gorm.DB) *gorm.DB) ([]Definition, error) {
+	var achievements []models.Achievement
+	if err := db.Scopes(scope).Order("points ASC, name ASC").Find(&achievements).Error; err != nil {
+		return nil, fmt.Errorf("failed to load achievements: %w", err)
+	}
+
+	var badges []models.Badge
+	if err := db.Scopes(scope).Order("family ASC, level ASC").Find(&badges).Error; err != nil {
+		return nil, fmt.Errorf("failed to load badges: %w", err)
+	}
+
+	defs := make([]Definition, 0, len(achievements)+len(badges))
+	for _, a := range achievements {
+		defs = append(defs, Definition{
+			ID:          a.ID,
+			Kind:        models.AwardKindAchievement,
+			Code:        a.Code,
+			Name:        a.Name,
+			Description: a.Description,
+			Category:    a.Category,
+			Icon:        a.Icon,
+			Rarity:      a.Rarity,
+			Points:      a.Points,
+			Metric:      a.Metric,
+			Threshold:   a.Threshold,
+		})
+	}
+	for _, b := range badges {
+		defs = append(defs, Definition{
+			ID:          b.ID,
+			Kind:        models.AwardKindBadge,
+			Code:        b.Code,
+			Family:      b.Family,
+			Name:        b.Name,
+			Description: b.Description,
+			Category:    b.Category,
+			Icon:        b.Icon,
+			Level:       b.Level,
+			Metric:      b.Metric,
+			Threshold:   b.Threshold,
+		})
+	}
+
+	return defs, nil
+}
+
+// awards loads user_achievements rows matching the filter together with
+// their definitions, including definitions that have since been retired
+func (s *Service) awards(db *gorm.DB, where string, args ...interface{}) ([]Award, error) {
+	var rows []models.UserAchievement
+	if err := db.Where(where, args...).Order("earned_at DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load awards: %w", err)
+	}
+	if len(rows) == 0 {
+		return []Award{}, nil
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, r := range rows {
+		ids[i] = r.DefinitionID
+	}
+
+	defs, err := s.definitionsByID(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	awards := make([]Award, 0, len(rows))
+	for _, r := range rows {
+		def, ok := defs[r.DefinitionID]
+		if !ok {
+			continue
+		}
+		awards = append(awards, Award{
+			Definition: def,
+			EarnedAt:   r.EarnedAt,
+			SourceType: r.SourceType,
+			SourceID:   r.SourceID,
+		})
+	}
+
+	return awards, nil
+}
+
+// definitionsByID loads definitions by ID, including retired ones
+func (s *Service) definitionsByID(db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]Definition, error) {
+	defs, err := loadDefinitions(db, func(q *gorm.DB) *gorm.DB {
+		return q.Where("id IN ?", ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]Definition, len(defs))
+	for _, d := range defs {
+		byID[d.ID] = d
+	}
+	return byID, nil
+}
+
+func (s *Service) earnedIDs(db *gorm.DB, userID uuid.UUID) (map[uuid.UUID]bool, error) {
+	var ids []uuid.UUID
+	if err := db.Model(&models.UserAchievement{}).
+		Where("user_id = ?", userID).
+		Pluck("definition_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to load earned awards: %w", err)
+	}
+	earned := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		earned[id] = true
+	}
+	return earned, nil
+}
+
+// source identifies what unlocked an award
+func source(e events.Event) (string, *uuid.UUID) {
+	switch p := e.Payload.(type) {
+	case events.TestCompletedPayload:
+		return "practice_test", &p.TestID
+	case events.AnswerRecordedPayload:
+		return "question", &p.QuestionID
+	case events.TopicMasteredPayload:
+		return "topic", &p.TopicID
+	case events.StreakPayload:
+		return "streak", nil
+	}
+	return "", nil
+}
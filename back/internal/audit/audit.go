@@ -0,0 +1,201 @@
+// Package audit writes the append-only audit log
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Entry describes one audited action
+type Entry struct {
+	ActorID    *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	IP         string
+	RequestID  string
+	Before     map[string]interface{}
+	After      map[string]interface{}
+	Details    map[string]interface{}
+}
+
+// Actor identifies who made a change and from which request
+type Actor struct {
+	ID        *uuid.UUID
+	IP        string
+	RequestID string
+}
+
+// Entry starts an entry attributed to the actor
+func (a Actor) Entry(action, entityType, entityID string) Entry {
+	return Entry{
+		ActorID:    a.ID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		IP:         a.IP,
+		RequestID:  a.RequestID,
+	}
+}
+
+// Record appends entry to the audit log. Pass the transaction making the
+// audited change so the entry commits with it.
+func Record(db *gorm.DB, entry Entry) error {
+	return db.Create(&models.AuditLog{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		IP:         entry.IP,
+		RequestID:  entry.RequestID,
+		Before:     models.JSONMap(entry.Before),
+		After:      models.JSONMap(entry.After),
+		Details:    models.JSONMap(entry.Details),
+	}).Error
+}
+
+// Pseudonym replaces personal data such as an email address or IP with a
+// keyed hash. Entries about the same subject still match each other, but
+// the log itself holds nothing that identifies a person.
+func Pseudonym(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Snapshot converts v to its JSON object form so it can be diffed and stored
+func Snapshot(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Diff reduces two snapshots to the keys whose values differ
+func Diff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	b := make(map[string]interface{})
+	a := make(map[string]interface{})
+	for k, v := range before {
+		if w, ok := after[k]; !ok || !reflect.DeepEqual(v, w) {
+			b[k] = v
+			if ok {
+				a[k] = w
+			}
+		}
+	}
+	for k, w := range after {
+		if _, ok := before[k]; !ok {
+			a[k] = w
+		}
+	}
+	return b, a
+}
+
+// Filter narrows an audit log query. Zero values match everything.
+type Filter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// Apply adds the filter's conditions to a query on audit_logs
+func (f Filter) Apply(db *gorm.DB) *gorm.DB {
+	if f.ActorID != nil {
+		db = db.Where("actor_id = ?", *f.ActorID)
+	}
+	if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if f.EntityType != "" {
+		db = db.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != "" {
+		db = db.Where("entity_id = ?", f.EntityID)
+	}
+	if f.RequestID != "" {
+		db = db.Where("request_id = ?", f.RequestID)
+	}
+	if f.From != nil {
+		db = db.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("created_at < ?", *f.To)
+	}
+	return db
+}
+
+// Scrub removes the personal data of a purged user from the log: the IP of
+// every entry by or about userID and any email in their snapshots, and the
+// email of entries written before lock events were pseudonymised, which is
+// replaced by pseudonym. It must run in the purge transaction, the only
+// place the trigger lets rows change.
+func Scrub(tx *gorm.DB, userID uuid.UUID, email, pseudonym string) (int64, error) {
+	if err := tx.Exec("SELECT set_config('audit.scrub', 'purge', true)").Error; err != nil {
+		return 0, err
+	}
+
+	result := tx.Exec(`
+		UPDATE audit_logs SET ip = '', before = before - 'email', after = after - 'email', details = details - 'email'
+		WHERE actor_id = ? OR (entity_type = 'user' AND entity_id = ?)`, userID, userID.String())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	scrubbed := result.RowsAffected
+
+	result = tx.Exec("UPDATE audit_logs SET entity_id = ? WHERE entity_type = 'account' AND entity_id = ?", pseudonym, email)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	scrubbed += result.RowsAffected
+
+	return scrubbed, tx.Exec("SELECT set_config('audit.scrub', '', true)").Error
+}
+
+// Protect installs a trigger that rejects UPDATE and DELETE on audit_logs,
+// so the log stays append-only even for direct SQL. The one exception is
+// Scrub, which may blank personal data but not change what was done, by
+// whom or when.
+func Protect(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE' AND current_setting('audit.scrub', true) = 'purge'
+		AND NEW.id = OLD.id
+		AND NEW.action = OLD.action
+		AND NEW.entity_type IS NOT DISTINCT FROM OLD.entity_type
+		AND NEW.actor_id IS NOT DISTINCT FROM OLD.actor_id
+		AND NEW.request_id IS NOT DISTINCT FROM OLD.request_id
+		AND NEW.created_at = OLD.created_at THEN
+		RETURN NEW;
+	END IF;
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs",
+		"CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to protect audit log: %w", err)
+		}
+	}
+	return nil
+}
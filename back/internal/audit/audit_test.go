@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type m = map[string]interface{}
+
+	tests := []struct {
+		name       string
+		before     m
+		after      m
+		wantBefore m
+		wantAfter  m
+	}{
+		{"unchanged", m{"name": "a", "n": 1.0}, m{"name": "a", "n": 1.0}, m{}, m{}},
+		{"changed value", m{"name": "a", "n": 1.0}, m{"name": "b", "n": 1.0}, m{"name": "a"}, m{"name": "b"}},
+		{"removed key", m{"name": "a", "note": "x"}, m{"name": "a"}, m{"note": "x"}, m{}},
+		{"added key", m{"name": "a"}, m{"name": "a", "note": "x"}, m{}, m{"note": "x"}},
+		{"nested value", m{"tags": []interface{}{"a"}}, m{"tags": []interface{}{"a", "b"}}, m{"tags": []interface{}{"a"}}, m{"tags": []interface{}{"a", "b"}}},
+		{"created", nil, m{"name": "a"}, m{}, m{"name": "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := Diff(tt.before, tt.after)
+			if !reflect.DeepEqual(before, tt.wantBefore) || !reflect.DeepEqual(after, tt.wantAfter) {
+				t.Errorf("Diff = (%v, %v), want (%v, %v)", before, after, tt.wantBefore, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	type role struct {
+		Name   string `json:"name"`
+		Secret string `json:"-"`
+	}
+	got, err := Snapshot(role{Name: "editor", Secret: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (map[string]interface{}{"name": "editor"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot = %v, want %v", got, want)
+	}
+}
+
+func TestPseudonym(t *testing.T) {
+	key := []byte("audit key")
+	a := Pseudonym(key, "user@example.com")
+	if a != Pseudonym(key, "user@example.com") {
+		t.Error("Pseudonym is not stable")
+	}
+	if a == Pseudonym(key, "other@example.com") || a == Pseudonym([]byte("other key"), "user@example.com") {
+		t.Error("Pseudonym collides")
+	}
+}
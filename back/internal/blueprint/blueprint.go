@@ -0,0 +1,372 @@
+// Package blueprint turns the NPPE syllabus weights stored on topics into a
+// concrete question allocation for a full-length practice exam.
+//
+// Allocation happens in three levels: topics by Topic.Weight, sub-topics
+// within each topic, then difficulties within each sub-topic by the
+// configured mix. Every level uses largest-remainder rounding and is capped
+// by the number of active questions actually available, so a pool that is
+// too small spills its shortfall onto its siblings instead of failing.
+package blueprint
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Difficulties lists the difficulty levels in allocation order
+var Difficulties = []string{"easy", "medium", "hard"}
+
+// TopicSpec describes a topic and its share of the exam
+type TopicSpec struct {
+	ID        uuid.UUID
+	Code      string
+	Name      string
+	Weight    float64
+	SubTopics []SubTopicSpec
+}
+
+// SubTopicSpec describes a sub-topic and its share within its topic
+type SubTopicSpec struct {
+	ID     uuid.UUID
+	Code   string
+	Name   string
+	Weight float64
+}
+
+// Cell identifies one bucket of the question pool. SubTopicID is uuid.Nil
+// for questions that are not assigned to a sub-topic.
+type Cell struct {
+	TopicID    uuid.UUID
+	SubTopicID uuid.UUID
+	Difficulty string
+}
+
+// Pool holds the number of available questions per cell
+type Pool map[Cell]int
+
+// Blueprint is the planned (and, once questions are drawn, realised)
+// composition of an exam
+type Blueprint struct {
+	Requested  int               `json:"requested"`
+	Allocated  int               `json:"allocated"`
+	Shortfall  int               `json:"shortfall"`
+	Difficulty map[string]int    `json:"difficulty"`
+	Topics     []TopicAllocation `json:"topics"`
+}
+
+// TopicAllocation reports how many questions a topic received
+type TopicAllocation struct {
+	TopicID    uuid.UUID            `json:"topic_id"`
+	Code       string               `json:"code"`
+	Name       string               `json:"name"`
+	Weight     float64              `json:"weight"`
+	Target     float64              `json:"target"`
+	Allocated  int                  `json:"allocated"`
+	Available  int                  `json:"available"`
+	Difficulty map[string]int       `json:"difficulty"`
+	SubTopics  []SubTopicAllocation `json:"sub_topics,omitempty"`
+}
+
+// SubTopicAllocation reports how many questions a sub-topic received.
+// A nil SubTopicID stands for the topic's unassigned questions.
+type SubTopicAllocation struct {
+	SubTopicID *uuid.UUID     `json:"sub_topic_id,omitempty"`
+	Code       string         `json:"code,omitempty"`
+	Name       string         `json:"name"`
+	Allocated  int            `json:"allocated"`
+	Available  int            `json:"available"`
+	Difficulty map[string]int `json:"difficulty"`
+}
+
+// Plan allocates total questions across topics, sub-topics and difficulties.
+// Difficulties without a positive share of mix are never drawn, so a short
+// level leaves a shortfall rather than being filled from the others.
+func Plan(total int, topics []TopicSpec, pool Pool, mix map[string]float64) *Blueprint {
+	pool = pool.restrict(mix)
+
+	bp := &Blueprint{
+		Requested:  total,
+		Difficulty: newDifficultyCounts(),
+		Topics:     make([]TopicAllocation, len(topics)),
+	}
+
+	weights := make([]float64, len(topics))
+	caps := make([]int, len(topics))
+	groups := make([][]subTopicGroup, len(topics))
+	weightSum := 0.0
+	for i, t := range topics {
+		weights[i] = t.Weight
+		weightSum += t.Weight
+		groups[i] = subTopicGroups(t, pool)
+		for _, g := range groups[i] {
+			caps[i] += g.available
+		}
+	}
+
+	topicCounts := Allocate(total, weights, caps)
+
+	for i, t := range topics {
+		ta := TopicAllocation{
+			TopicID:    t.ID,
+			Code:       t.Code,
+			Name:       t.Name,
+			Weight:     t.Weight,
+			Available:  caps[i],
+			Difficulty: newDifficultyCounts(),
+		}
+		if weightSum > 0 {
+			ta.Target = math.Round(float64(total)*t.Weight/weightSum*100) / 100
+		}
+
+		groupWeights := make([]float64, len(groups[i]))
+		groupCaps := make([]int, len(groups[i]))
+		for j, g := range groups[i] {
+			groupWeights[j] = g.weight
+			groupCaps[j] = g.available
+		}
+		groupCounts := Allocate(topicCounts[i], groupWeights, groupCaps)
+
+		for j, g := range groups[i] {
+			sa := SubTopicAllocation{
+				SubTopicID: g.id,
+				Code:       g.code,
+				Name:       g.name,
+				Available:  g.available,
+				Difficulty: newDifficultyCounts(),
+			}
+
+			diffWeights := make([]float64, len(Difficulties))
+			diffCaps := make([]int, len(Difficulties))
+			for k, d := range Difficulties {
+				diffWeights[k] = mix[d]
+				diffCaps[k] = pool[Cell{TopicID: t.ID, SubTopicID: g.key, Difficulty: d}]
+			}
+			diffCounts := Allocate(groupCounts[j], diffWeights, diffCaps)
+
+			for k, d := range Difficulties {
+				sa.Difficulty[d] = diffCounts[k]
+				sa.Allocated += diffCounts[k]
+				ta.Difficulty[d] += diffCounts[k]
+				bp.Difficulty[d] += diffCounts[k]
+			}
+
+			ta.Allocated += sa.Allocated
+			ta.SubTopics = append(ta.SubTopics, sa)
+		}
+
+		bp.Allocated += ta.Allocated
+		bp.Topics[i] = ta
+	}
+
+	bp.Shortfall = bp.Requested - bp.Allocated
+	return bp
+}
+
+// Cells returns the number of questions to draw from each cell of the pool
+func (bp *Blueprint) Cells() map[Cell]int {
+	cells := make(map[Cell]int)
+	for _, t := range bp.Topics {
+		for _, st := range t.SubTopics {
+			key := uuid.Nil
+			if st.SubTopicID != nil {
+				key = *st.SubTopicID
+			}
+			for d, n := range st.Difficulty {
+				if n > 0 {
+					cells[Cell{TopicID: t.TopicID, SubTopicID: key, Difficulty: d}] = n
+				}
+			}
+		}
+	}
+	return cells
+}
+
+// Allocate distributes total across items proportionally to weights using
+// largest-remainder rounding, never giving an item more than its cap
+// (a negative cap means unlimited). Capacity left unused by capped items is
+// redistributed to the others; when every remaining weight is zero the
+// remainder is spread evenly. The result sums to min(total, sum of caps).
+func Allocate(total int, weights []float64, caps []int) []int {
+	n := len(weights)
+	counts := make([]int, n)
+	if n == 0 || total <= 0 {
+		return counts
+	}
+
+	capOf := func(i int) int {
+		if caps == nil || caps[i] < 0 {
+			return math.MaxInt32
+		}
+		return caps[i]
+	}
+
+	open := make([]bool, n)
+	for i := range open {
+		open[i] = capOf(i) > 0
+	}
+	remaining := total
+
+	for remaining > 0 {
+		active := make([]int, 0, n)
+		weightSum := 0.0
+		for i := 0; i < n; i++ {
+			if open[i] {
+				active = append(active, i)
+				weightSum += math.Max(weights[i], 0)
+			}
+		}
+		if len(active) == 0 {
+			break
+		}
+
+		share := func(i int) float64 {
+			if weightSum == 0 {
+				return 1 / float64(len(active))
+			}
+			return math.Max(weights[i], 0) / weightSum
+		}
+
+		// Items whose exact quota reaches their cap are saturated first; the
+		// freed capacity is shared out again in the next pass.
+		saturated := false
+		for _, i := range active {
+			room := capOf(i) - counts[i]
+			if float64(remaining)*share(i) >= float64(room) {
+				counts[i] += room
+				remaining -= room
+				open[i] = false
+				saturated = true
+			}
+		}
+		if saturated {
+			continue
+		}
+
+		type remainder struct {
+			index int
+			frac  float64
+		}
+		rems := make([]remainder, 0, len(active))
+		assigned := 0
+		for _, i := range active {
+			quota := float64(remaining) * share(i)
+			whole := int(math.Floor(quota))
+			counts[i] += whole
+			assigned += whole
+			rems = append(rems, remainder{index: i, frac: quota - float64(whole)})
+		}
+
+		sort.SliceStable(rems, func(a, b int) bool {
+			return rems[a].frac > rems[b].frac
+		})
+
+		left := remaining - assigned
+		for _, r := range rems {
+			if left == 0 {
+				break
+			}
+			if counts[r.index] < capOf(r.index) {
+				counts[r.index]++
+				left--
+			}
+		}
+		remaining = 0
+	}
+
+	return counts
+}
+
+type subTopicGroup struct {
+	id        *uuid.UUID
+	key       uuid.UUID
+	code      string
+	name      string
+	weight    float64
+	available int
+}
+
+// subTopicGroups lists a topic's sub-topics plus a trailing group for its
+// unassigned questions. Explicit sub-topic weights win; without them every
+// group counts the same.
+func subTopicGroups(t TopicSpec, pool Pool) []subTopicGroup {
+	explicit := false
+	for _, st := range t.SubTopics {
+		if st.Weight > 0 {
+			explicit = true
+			break
+		}
+	}
+
+	groups := make([]subTopicGroup, 0, len(t.SubTopics)+1)
+	for _, st := range t.SubTopics {
+		id := st.ID
+		weight := 1.0
+		if explicit {
+			weight = st.Weight
+		}
+		groups = append(groups, subTopicGroup{
+			id:        &id,
+			key:       st.ID,
+			code:      st.Code,
+			name:      st.Name,
+			weight:    weight,
+			available: pool.groupSize(t.ID, st.ID),
+		})
+	}
+
+	if general := pool.groupSize(t.ID, uuid.Nil); general > 0 || len(groups) == 0 {
+		weight := 1.0
+		if explicit {
+			weight = 0
+		}
+		groups = append(groups, subTopicGroup{
+			key:       uuid.Nil,
+			name:      "General",
+			weight:    weight,
+			available: general,
+		})
+	}
+
+	return groups
+}
+
+// restrict drops the cells of difficulties with no share of mix. A mix
+// without any positive share leaves the pool as it is.
+func (p Pool) restrict(mix map[string]float64) Pool {
+	weighted := false
+	for _, w := range mix {
+		if w > 0 {
+			weighted = true
+			break
+		}
+	}
+	if !weighted {
+		return p
+	}
+
+	restricted := make(Pool, len(p))
+	for cell, n := range p {
+		if mix[cell.Difficulty] > 0 {
+			restricted[cell] = n
+		}
+	}
+	return restricted
+}
+
+func (p Pool) groupSize(topicID, subTopicID uuid.UUID) int {
+	total := 0
+	for _, d := range Difficulties {
+		total += p[Cell{TopicID: topicID, SubTopicID: subTopicID, Difficulty: d}]
+	}
+	return total
+}
+
+func newDifficultyCounts() map[string]int {
+	counts := make(map[string]int, len(Difficulties))
+	for _, d := range Difficulties {
+		counts[d] = 0
+	}
+	return counts
+}
@@ -0,0 +1,78 @@
+package blueprint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		weights []float64
+		caps    []int
+		want    []int
+	}{
+		{"even split, tie to the first", 10, []float64{1, 1, 1}, nil, []int{4, 3, 3}},
+		{"proportional", 10, []float64{3, 1}, nil, []int{8, 2}},
+		{"cap spills onto the rest", 10, []float64{1, 1}, []int{2, -1}, []int{2, 8}},
+		{"cap spill is shared", 7, []float64{1, 1, 1}, []int{1, -1, -1}, []int{1, 3, 3}},
+		{"total beyond every cap", 10, []float64{1, 1}, []int{3, 4}, []int{3, 4}},
+		{"zero weights spread evenly", 5, []float64{0, 0}, nil, []int{3, 2}},
+		{"negative weight counts as zero", 4, []float64{-1, 1}, nil, []int{0, 4}},
+		{"empty item is skipped", 4, []float64{1, 1}, []int{0, -1}, []int{0, 4}},
+		{"nothing to allocate", 0, []float64{1, 1}, nil, []int{0, 0}},
+		{"no items", 5, nil, nil, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allocate(tt.total, tt.weights, tt.caps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate(%d, %v, %v) = %v, want %v", tt.total, tt.weights, tt.caps, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	large := TopicSpec{ID: uuid.New(), Code: "A", Weight: 0.6}
+	small := TopicSpec{ID: uuid.New(), Code: "B", Weight: 0.4}
+	pool := Pool{
+		{TopicID: large.ID, Difficulty: "medium"}: 100,
+		{TopicID: small.ID, Difficulty: "easy"}:   2,
+	}
+	mix := map[string]float64{"easy": 0.3, "medium": 0.5, "hard": 0.2}
+
+	tests := []struct {
+		name      string
+		total     int
+		mix       map[string]float64
+		want      map[Cell]int
+		shortfall int
+	}{
+		{"small topic spills onto the large one", 10, mix, map[Cell]int{
+			{TopicID: large.ID, Difficulty: "medium"}: 8,
+			{TopicID: small.ID, Difficulty: "easy"}:   2,
+		}, 0},
+		{"pool too small", 200, mix, map[Cell]int{
+			{TopicID: large.ID, Difficulty: "medium"}: 100,
+			{TopicID: small.ID, Difficulty: "easy"}:   2,
+		}, 98},
+		{"single difficulty is not filled from others", 10, map[string]float64{"easy": 1}, map[Cell]int{
+			{TopicID: small.ID, Difficulty: "easy"}: 2,
+		}, 8},
+		{"single difficulty missing from the pool", 10, map[string]float64{"hard": 1}, map[Cell]int{}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp := Plan(tt.total, []TopicSpec{large, small}, pool, tt.mix)
+			if got := bp.Cells(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cells = %v, want %v", got, tt.want)
+			}
+			if bp.Shortfall != tt.shortfall || bp.Allocated+bp.Shortfall != tt.total {
+				t.Errorf("Allocated %d, Shortfall %d, want shortfall %d of %d", bp.Allocated, bp.Shortfall, tt.shortfall, tt.total)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package blueprint
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Builder draws exam questions from the database according to a blueprint
+type Builder struct {
+	db  *gorm.DB
+	mix map[string]float64
+}
+
+// NewBuilder creates a blueprint builder with the given difficulty mix
+func NewBuilder(db *gorm.DB, mix map[string]float64) *Builder {
+	return &Builder{db: db, mix: mix}
+}
+
+// Options narrows the pool an exam is drawn from
+type Options struct {
+	// Province restricts the pool to national questions plus those of the
+	// given province. Empty means no restriction.
+	Province string
+	// Difficulty, when set, replaces the configured mix with a single level
+	Difficulty string
+}
+
+// Build plans an exam of total questions and draws them at random within
+// each cell. The returned blueprint reflects what was actually drawn.
+func (b *Builder) Build(ctx context.Context, total int, opts Options) ([]models.Question, *Blueprint, error) {
+	db := b.db.WithContext(ctx)
+
+	topics, err := b.loadTopics(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pool, err := b.loadPool(db, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mix := b.mix
+	if opts.Difficulty != "" {
+		mix = map[string]float64{opts.Difficulty: 1}
+	}
+
+	plan := Plan(total, topics, pool, mix)
+
+	var ids []uuid.UUID
+	for cell, n := range plan.Cells() {
+		var cellIDs []uuid.UUID
+		query := b.poolQuery(db, opts).
+			Where("topic_id = ? AND difficulty = ?", cell.TopicID, cell.Difficulty)
+		if cell.SubTopicID == uuid.Nil {
+			query = query.Where("sub_topic_id IS NULL")
+		} else {
+			query = query.Where("sub_topic_id = ?", cell.SubTopicID)
+		}
+		if err := query.Order("RANDOM()").Limit(n).Pluck("id", &cellIDs).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to draw questions: %w", err)
+		}
+		ids = append(ids, cellIDs...)
+	}
+
+	if len(ids) == 0 {
+		return nil, plan, nil
+	}
+
+	var questions []models.Question
+	if err := db.Where("id IN ?", ids).
+		Preload("Topic").
+		Preload("SubTopic").
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Find(&questions).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load questions: %w", err)
+	}
+
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+
+	return questions, Realise(plan, questions), nil
+}
+
+// Realise recounts a planned blueprint from the questions actually drawn,
+// which can differ from the plan if the bank changed in between
+func Realise(plan *Blueprint, questions []models.Question) *Blueprint {
+	drawn := make(map[Cell]int)
+	for _, q := range questions {
+		key := uuid.Nil
+		if q.SubTopicID != nil {
+			key = *q.SubTopicID
+		}
+		drawn[Cell{TopicID: q.TopicID, SubTopicID: key, Difficulty: q.Difficulty}]++
+	}
+
+	realised := &Blueprint{
+		Requested:  plan.Requested,
+		Difficulty: newDifficultyCounts(),
+		Topics:     make([]TopicAllocation, len(plan.Topics)),
+	}
+
+	for i, t := range plan.Topics {
+		ta := t
+		ta.Allocated = 0
+		ta.Difficulty = newDifficultyCounts()
+		ta.SubTopics = make([]SubTopicAllocation, len(t.SubTopics))
+
+		for j, st := range t.SubTopics {
+			sa := st
+			sa.Allocated = 0
+			sa.Difficulty = newDifficultyCounts()
+
+			key := uuid.Nil
+			if st.SubTopicID != nil {
+				key = *st.SubTopicID
+			}
+			for _, d := range Difficulties {
+				n := drawn[Cell{TopicID: t.TopicID, SubTopicID: key, Difficulty: d}]
+				sa.Difficulty[d] = n
+				sa.Allocated += n
+				ta.Difficulty[d] += n
+				realised.Difficulty[d] += n
+			}
+
+			ta.Allocated += sa.Allocated
+			ta.SubTopics[j] = sa
+		}
+
+		realised.Allocated += ta.Allocated
+		realised.Topics[i] = ta
+	}
+
+	realised.Shortfall = realised.Requested - realised.Allocated
+	return realised
+}
+
+func (b *Builder) loadTopics(db *gorm.DB) ([]TopicSpec, error) {
+	var topics []models.Topic
+	if err := db.Order("\"order\" ASC").Find(&topics).Error; err != nil {
+		return nil, fmt.Errorf("failed to load topics: %w", err)
+	}
+
+	var subTopics []models.SubTopic
+	if err := db.Order("\"order\" ASC").Find(&subTopics).Error; err != nil {
+		return nil, fmt.Errorf("failed to load subtopics: %w", err)
+	}
+
+	byTopic := make(map[uuid.UUID][]SubTopicSpec)
+	for _, st := range subTopics {
+		byTopic[st.TopicID] = append(byTopic[st.TopicID], SubTopicSpec{
+			ID:     st.ID,
+			Code:   st.Code,
+			Name:   st.Name,
+			Weight: st.Weight,
+		})
+	}
+
+	specs := make([]TopicSpec, len(topics))
+	for i, t := range topics {
+		specs[i] = TopicSpec{
+			ID:        t.ID,
+			Code:      t.Code,
+			Name:      t.Name,
+			Weight:    t.Weight,
+			SubTopics: byTopic[t.ID],
+		}
+	}
+
+	return specs, nil
+}
+
+func (b *Builder) loadPool(db *gorm.DB, opts Options) (Pool, error) {
+	var rows []struct {
+		TopicID    uuid.UUID
+		SubTopicID *uuid.UUID
+		Difficulty string
+		Count      int
+	}
+
+	if err := b.poolQuery(db, opts).
+		Select("topic_id, sub_topic_id, difficulty, COUNT(*) AS count").
+		Group("topic_id, sub_topic_id, difficulty").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count question pool: %w", err)
+	}
+
+	pool := make(Pool, len(rows))
+	for _, r := range rows {
+		key := uuid.Nil
+		if r.SubTopicID != nil {
+			key = *r.SubTopicID
+		}
+		pool[Cell{TopicID: r.TopicID, SubTopicID: key, Difficulty: r.Difficulty}] += r.Count
+	}
+
+	return pool, nil
+}
+
+func (b *Builder) poolQuery(db *gorm.DB, opts Options) *gorm.DB {
+	query := db.Model(&models.Question{}).Where("is_active = ?", true)
+	if opts.Province != "" {
+		query = query.Where("province IS NULL OR province = ?", opts.Province)
+	}
+	if opts.Difficulty != "" {
+		query = query.Where("difficulty = ?", opts.Difficulty)
+	}
+	return query
+}
@@ -0,0 +1,346 @@
+// Package bookmarks keeps the questions users save for later, sorted into
+// folders of their own and annotated with private notes.
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxFolders caps the number of folders a user can create
+const MaxFolders = 50
+
+var (
+	ErrNotFound         = errors.New("bookmark not found")
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrFolderNotFound   = errors.New("folder not found")
+	ErrFolderExists     = errors.New("a folder with this name already exists")
+	ErrTooManyFolders   = errors.New("folder limit reached")
+	ErrInvalidName      = errors.New("folder name is required")
+)
+
+// Service manages bookmarks and bookmark folders
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new bookmark service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Change sets the folder or the note of a bookmark. Nil fields are left as
+// they are; a FolderID of uuid.Nil takes the bookmark out of its folder.
+type Change struct {
+	FolderID *uuid.UUID
+	Note     *string
+}
+
+// Filter narrows a bookmark listing. A FolderID of uuid.Nil lists the
+// bookmarks that are in no folder.
+type Filter struct {
+	FolderID *uuid.UUID
+	Limit    int
+	Offset   int
+}
+
+// Folder is a folder with the number of bookmarks in it
+type Folder struct {
+	models.BookmarkFolder
+	Bookmarks int64 `gorm:"->" json:"bookmarks"`
+}
+
+// Save bookmarks an active question, or applies change to the existing
+// bookmark. created reports whether the bookmark is new.
+func (s *Service) Save(ctx context.Context, userID, questionID uuid.UUID, change Change) (bookmark *models.UserBookmark, created bool, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Question{}).Where("id = ? AND is_active = ?", questionID, true).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrQuestionNotFound
+		}
+
+		updates, err := s.changes(tx, userID, change)
+		if err != nil {
+			return err
+		}
+
+		insert := models.UserBookmark{UserID: userID, QuestionID: questionID}
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "question_id"}},
+			DoNothing: true,
+		}).Create(&insert)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected > 0
+
+		var b models.UserBookmark
+		if err := tx.Where("user_id = ? AND question_id = ?", userID, questionID).First(&b).Error; err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&b).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&b, "id = ?", b.ID).Error; err != nil {
+				return err
+			}
+		}
+		bookmark = &b
+		return nil
+	})
+	return bookmark, created, err
+}
+
+// Update applies change to an existing bookmark
+func (s *Service) Update(ctx context.Context, userID, questionID uuid.UUID, change Change) (*models.UserBookmark, error) {
+	var bookmark models.UserBookmark
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND question_id = ?", userID, questionID).First(&bookmark).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		updates, err := s.changes(tx, userID, change)
+		if err != nil || len(updates) == 0 {
+			return err
+		}
+		if err := tx.Model(&bookmark).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(&bookmark, "id = ?", bookmark.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// changes turns change into column updates, checking the folder is the
+// user's own
+func (s *Service) changes(tx *gorm.DB, userID uuid.UUID, change Change) (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+	if change.FolderID != nil {
+		if *change.FolderID == uuid.Nil {
+			updates["folder_id"] = nil
+		} else {
+			if _, err := s.folder(tx, userID, *change.FolderID); err != nil {
+				return nil, err
+			}
+			updates["folder_id"] = *change.FolderID
+		}
+	}
+	if change.Note != nil {
+		updates["note"] = strings.TrimSpace(*change.Note)
+	}
+	return updates, nil
+}
+
+// Remove deletes the user's bookmark of a question
+func (s *Service) Remove(ctx context.Context, userID, questionID uuid.UUID) error {
+	result := s.db.WithContext(ctx).Where("user_id = ? AND question_id = ?", userID, questionID).Delete(&models.UserBookmark{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns the user's bookmarks, newest first, with their questions
+// and the total matching filter
+func (s *Service) List(ctx context.Context, userID uuid.UUID, filter Filter) ([]models.UserBookmark, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.UserBookmark{}).Where("user_id = ?", userID)
+	if filter.FolderID != nil {
+		if *filter.FolderID == uuid.Nil {
+			query = query.Where("folder_id IS NULL")
+		} else {
+			query = query.Where("folder_id = ?", *filter.FolderID)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var bookmarks []models.UserBookmark
+	err := query.Preload("Question.Topic").
+		Preload("Question.SubTopic").
+		Preload("Question.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&bookmarks).Error
+	return bookmarks, total, err
+}
+
+// Marked returns which of the given questions the user has bookmarked
+func (s *Service) Marked(ctx context.Context, userID uuid.UUID, questionIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	marked := make(map[uuid.UUID]bool)
+	if len(questionIDs) == 0 {
+		return marked, nil
+	}
+
+	var ids []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.UserBookmark{}).
+		Where("user_id = ? AND question_id IN ?", userID, questionIDs).
+		Pluck("question_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		marked[id] = true
+	}
+	return marked, nil
+}
+
+// QuestionIDs returns a subquery selecting the questions the user has
+// bookmarked, in folderID only when it is set
+func (s *Service) QuestionIDs(userID uuid.UUID, folderID *uuid.UUID) *gorm.DB {
+	query := s.db.Model(&models.UserBookmark{}).Select("question_id").Where("user_id = ?", userID)
+	if folderID != nil {
+		query = query.Where("folder_id = ?", *folderID)
+	}
+	return query
+}
+
+// Folders returns the user's folders by name with their bookmark counts
+func (s *Service) Folders(ctx context.Context, userID uuid.UUID) ([]Folder, error) {
+	var folders []Folder
+	err := s.db.WithContext(ctx).Model(&models.BookmarkFolder{}).
+		Select("bookmark_folders.*, (SELECT COUNT(*) FROM user_bookmarks b WHERE b.folder_id = bookmark_folders.id) AS bookmarks").
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+// Folder returns one of the user's folders
+func (s *Service) Folder(ctx context.Context, userID, id uuid.UUID) (*models.BookmarkFolder, error) {
+	return s.folder(s.db.WithContext(ctx), userID, id)
+}
+
+func (s *Service) folder(db *gorm.DB, userID, id uuid.UUID) (*models.BookmarkFolder, error) {
+	var folder models.BookmarkFolder
+	err := db.Where("id = ? AND user_id = ?", id, userID).First(&folder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFolderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// CreateFolder adds a folder. Names are unique per user, ignoring case.
+func (s *Service) CreateFolder(ctx context.Context, userID uuid.UUID, name string) (*models.BookmarkFolder, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	var folder models.BookmarkFolder
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialises folder changes of the same user
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.BookmarkFolder{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxFolders {
+			return ErrTooManyFolders
+		}
+		if err := nameTaken(tx, userID, name, uuid.Nil); err != nil {
+			return err
+		}
+
+		folder = models.BookmarkFolder{UserID: userID, Name: name}
+		return tx.Create(&folder).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// RenameFolder renames one of the user's folders
+func (s *Service) RenameFolder(ctx context.Context, userID, id uuid.UUID, name string) (*models.BookmarkFolder, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	var folder *models.BookmarkFolder
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		var err error
+		if folder, err = s.folder(tx, userID, id); err != nil {
+			return err
+		}
+		if err := nameTaken(tx, userID, name, id); err != nil {
+			return err
+		}
+		if err := tx.Model(folder).Update("name", name).Error; err != nil {
+			return err
+		}
+		folder.Name = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// DeleteFolder deletes one of the user's folders. Its bookmarks are kept,
+// in no folder.
+func (s *Service) DeleteFolder(ctx context.Context, userID, id uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		folder, err := s.folder(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.UserBookmark{}).Where("folder_id = ?", folder.ID).Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(folder).Error
+	})
+}
+
+// nameTaken reports ErrFolderExists when another of the user's folders,
+// not except, already has name
+func nameTaken(tx *gorm.DB, userID uuid.UUID, name string, except uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.BookmarkFolder{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, except).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrFolderExists
+	}
+	return nil
+}
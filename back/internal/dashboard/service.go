@@ -0,0 +1,439 @@
+// Package dashboard aggregates a user's study history into the dashboard
+// summary. Summaries are cached in Redis per user and dropped whenever an
+// event changes the underlying history.
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/mastery"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/internal/readiness"
+	"github.com/nppe-pro/api/internal/streak"
+	"github.com/nppe-pro/api/pkg/database"
+	"gorm.io/gorm"
+)
+
+// Activity types in Summary.RecentActivity
+const (
+	ActivityPracticeTest = "practice_test"
+	ActivityPractice     = "practice"
+)
+
+// weakTopicMinAttempts keeps a topic off the weak list until there is
+// enough evidence to judge it
+const weakTopicMinAttempts = 3
+
+// Summary is the dashboard for one user
+type Summary struct {
+	OverallProgress           int                `json:"overall_progress"`
+	QuestionsInBank           int                `json:"questions_in_bank"`
+	QuestionsSeen             int                `json:"questions_seen"`
+	StudyStreak               int                `json:"study_streak"`
+	LongestStreak             int                `json:"longest_streak"`
+	QuestionsCompleted        int                `json:"questions_completed"`
+	QuestionsCorrect          int                `json:"questions_correct"`
+	AccuracyRate              float64            `json:"accuracy_rate"`
+	PracticeTestsTaken        int                `json:"practice_tests_taken"`
+	AverageTestScore          float64            `json:"average_test_score"`
+	TimeStudiedHours          float64            `json:"time_studied_hours"`
+	PassProbability           int                `json:"pass_probability"`
+	Readiness                 readiness.Estimate `json:"readiness"`
+	DaysUntilExam             int                `json:"days_until_exam"`
+	RecommendedStudyTimeDaily int                `json:"recommended_study_time_daily"`
+	TopicMastery              []TopicMastery     `json:"topic_mastery"`
+	WeakTopics                []TopicMastery     `json:"weak_topics"`
+	RecentActivity            []Activity         `json:"recent_activity"`
+	GeneratedAt               time.Time          `json:"generated_at"`
+}
+
+// TopicMastery is a user's standing in one topic of the bank
+type TopicMastery struct {
+	TopicID            uuid.UUID  `json:"topic_id"`
+	Code               string     `json:"code"`
+	Name               string     `json:"name"`
+	Weight             float64    `json:"weight"`
+	MasteryPercentage  float64    `json:"mastery_percentage"`
+	QuestionsAttempted int        `json:"questions_attempted"`
+	QuestionsCorrect   int        `json:"questions_correct"`
+	QuestionsSeen      int        `json:"questions_seen"`
+	QuestionsInBank    int        `json:"questions_in_bank"`
+	Progress           int        `json:"progress"` // percentage of the topic's bank seen
+	Mastered           bool       `json:"mastered"`
+	LastPracticed      *time.Time `json:"last_practiced,omitempty"`
+}
+
+// Activity is one entry of the recent activity feed: a completed practice
+// test, or a day of practice answers in one topic
+type Activity struct {
+	Type              string     `json:"type"`
+	ID                *uuid.UUID `json:"id,omitempty"`
+	Title             string     `json:"title"`
+	TopicID           *uuid.UUID `json:"topic_id,omitempty"`
+	Score             *float64   `json:"score,omitempty"`
+	QuestionsAnswered int        `json:"questions_answered"`
+	QuestionsCorrect  int        `json:"questions_correct"`
+	OccurredAt        time.Time  `json:"occurred_at"`
+}
+
+// Service builds and caches dashboards
+type Service struct {
+	db        *gorm.DB
+	redis     *database.RedisClient
+	config    *config.Config
+	streaks   *streak.Service
+	readiness *readiness.Service
+}
+
+// NewService creates a new dashboard service
+func NewService(db *gorm.DB, redis *database.RedisClient, cfg *config.Config, streaks *streak.Service) *Service {
+	return &Service{
+		db:        db,
+		redis:     redis,
+		config:    cfg,
+		streaks:   streaks,
+		readiness: readiness.NewService(db, cfg),
+	}
+}
+
+// Subscribe drops a user's cached dashboard whenever their history or
+// profile changes. The profile decides the province question bank and the
+// days left until the exam.
+func (s *Service) Subscribe(bus *events.Bus) {
+	invalidate := func(ctx context.Context, e events.Event) error {
+		return s.Invalidate(ctx, e.UserID)
+	}
+	for _, t := range []events.Type{
+		events.AnswerRecorded,
+		events.TestCompleted,
+		events.ModuleProgressed,
+		events.StreakBroken,
+		events.ProfileUpdated,
+	} {
+		bus.Subscribe(t, invalidate)
+	}
+}
+
+// Invalidate drops the user's cached dashboard
+func (s *Service) Invalidate(ctx context.Context, userID uuid.UUID) error {
+	return s.redis.Delete(ctx, cacheKey(userID))
+}
+
+// Get returns the user's dashboard, from the cache when possible. A cache
+// failure is logged and falls through to a fresh build.
+func (s *Service) Get(ctx context.Context, userID uuid.UUID) (*Summary, error) {
+	key := cacheKey(userID)
+	if cached, err := s.redis.Get(ctx, key); err == nil {
+		var summary Summary
+		if err := json.Unmarshal([]byte(cached), &summary); err == nil {
+			return &summary, nil
+		}
+	}
+
+	summary, err := s.Build(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(summary); err == nil {
+		if err := s.redis.Set(ctx, key, data, s.config.Dashboard.CacheTTL); err != nil {
+			log.Printf("⚠️ Failed to cache dashboard for user %s: %v", userID, err)
+		}
+	}
+	return summary, nil
+}
+
+// Build aggregates the user's dashboard as of now, bypassing the cache
+func (s *Service) Build(ctx context.Context, userID uuid.UUID, now time.Time) (*Summary, error) {
+	db := s.db.WithContext(ctx)
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var stats models.UserStats
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to load stats: %w", err)
+	}
+
+	summary := &Summary{
+		StudyStreak:               s.streaks.Current(&user, now),
+		LongestStreak:             user.LongestStreak,
+		QuestionsCompleted:        stats.QuestionsCompleted,
+		QuestionsCorrect:          stats.QuestionsCorrect,
+		PracticeTestsTaken:        stats.PracticeTestsTaken,
+		AverageTestScore:          stats.AverageTestScore,
+		TimeStudiedHours:          float64(stats.TimeStudiedSeconds) / 3600.0,
+		RecommendedStudyTimeDaily: 90, // Default recommendation
+		GeneratedAt:               now,
+	}
+
+	if stats.QuestionsCompleted > 0 {
+		summary.AccuracyRate = float64(stats.QuestionsCorrect) / float64(stats.QuestionsCompleted) * 100
+	}
+
+	if user.ExamDate != nil {
+		if days := int(user.ExamDate.Sub(now).Hours() / 24); days > 0 {
+			summary.DaysUntilExam = days
+		}
+	}
+
+	topics, err := s.topicMastery(db, &user)
+	if err != nil {
+		return nil, err
+	}
+	summary.TopicMastery = topics
+	summary.WeakTopics = weakTopics(topics, s.config.Dashboard.WeakTopicCount)
+
+	for _, t := range topics {
+		summary.QuestionsInBank += t.QuestionsInBank
+		summary.QuestionsSeen += t.QuestionsSeen
+	}
+	summary.OverallProgress = percentage(summary.QuestionsSeen, summary.QuestionsInBank)
+
+	estimate, err := s.readiness.Estimate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Readiness = estimate
+	summary.PassProbability = int(math.Round(estimate.PassProbability))
+
+	activity, err := s.recentActivity(db, &user)
+	if err != nil {
+		return nil, err
+	}
+	summary.RecentActivity = activity
+
+	return summary, nil
+}
+
+// topicMastery lists every topic with the size of its active bank for the
+// user's province and the user's standing in it
+func (s *Service) topicMastery(db *gorm.DB, user *models.User) ([]TopicMastery, error) {
+	var topics []models.Topic
+	if err := db.Order(`"order" ASC, name ASC`).Find(&topics).Error; err != nil {
+		return nil, fmt.Errorf("failed to load topics: %w", err)
+	}
+
+	type count struct {
+		TopicID uuid.UUID
+		Count   int
+	}
+
+	var bank []count
+	if err := bankQuery(db, user.Province).
+		Select("topic_id, COUNT(*) AS count").
+		Group("topic_id").
+		Scan(&bank).Error; err != nil {
+		return nil, fmt.Errorf("failed to count question bank: %w", err)
+	}
+
+	var seen []count
+	if err := bankQuery(db, user.Province).
+		Select("questions.topic_id, COUNT(DISTINCT questions.id) AS count").
+		Joins("JOIN (?) AS answers ON answers.question_id = questions.id", answers(db, user.ID)).
+		Group("questions.topic_id").
+		Scan(&seen).Error; err != nil {
+		return nil, fmt.Errorf("failed to count answered questions: %w", err)
+	}
+
+	var masteries []models.UserTopicMastery
+	if err := db.Where("user_id = ?", user.ID).Find(&masteries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load topic mastery: %w", err)
+	}
+
+	bankByTopic := make(map[uuid.UUID]int, len(bank))
+	for _, c := range bank {
+		bankByTopic[c.TopicID] = c.Count
+	}
+	seenByTopic := make(map[uuid.UUID]int, len(seen))
+	for _, c := range seen {
+		seenByTopic[c.TopicID] = c.Count
+	}
+	masteryByTopic := make(map[uuid.UUID]models.UserTopicMastery, len(masteries))
+	for _, m := range masteries {
+		masteryByTopic[m.TopicID] = m
+	}
+
+	result := make([]TopicMastery, len(topics))
+	for i, t := range topics {
+		tm := TopicMastery{
+			TopicID:         t.ID,
+			Code:            t.Code,
+			Name:            t.Name,
+			Weight:          t.Weight,
+			QuestionsSeen:   seenByTopic[t.ID],
+			QuestionsInBank: bankByTopic[t.ID],
+		}
+		tm.Progress = percentage(tm.QuestionsSeen, tm.QuestionsInBank)
+
+		if m, ok := masteryByTopic[t.ID]; ok {
+			tm.MasteryPercentage = math.Round(m.MasteryPercentage*10) / 10
+			tm.QuestionsAttempted = m.QuestionsAttempted
+			tm.QuestionsCorrect = m.QuestionsCorrect
+			tm.Mastered = mastery.Mastered(&m)
+			last := m.LastPracticed
+			tm.LastPracticed = &last
+		}
+		result[i] = tm
+	}
+
+	return result, nil
+}
+
+// recentActivity merges completed practice tests with the user's answers,
+// from practice and from those tests, grouped by topic and local day,
+// newest first
+func (s *Service) recentActivity(db *gorm.DB, user *models.User) ([]Activity, error) {
+	limit := s.config.Dashboard.RecentActivity
+	activity := make([]Activity, 0, limit)
+	if limit <= 0 {
+		return activity, nil
+	}
+
+	var tests []models.PracticeTest
+	if err := db.Where("user_id = ? AND status = ?", user.ID, "completed").
+		Order("completed_at DESC").
+		Limit(limit).
+		Find(&tests).Error; err != nil {
+		return nil, fmt.Errorf("failed to load recent tests: %w", err)
+	}
+	for _, t := range tests {
+		id, score := t.ID, t.Score
+		at := t.StartedAt
+		if t.CompletedAt != nil {
+			at = *t.CompletedAt
+		}
+		activity = append(activity, Activity{
+			Type:              ActivityPracticeTest,
+			ID:                &id,
+			Title:             testTitle(t.TestType),
+			Score:             &score,
+			QuestionsAnswered: t.TotalQuestions,
+			QuestionsCorrect:  t.CorrectAnswers,
+			OccurredAt:        at,
+		})
+	}
+
+	var sessions []struct {
+		TopicID  uuid.UUID
+		Name     string
+		Answered int
+		Correct  int
+		LastAt   time.Time
+		Day      time.Time
+	}
+	tz := s.streaks.Location(user).String()
+	if err := db.Table("(?) AS answers", answers(db, user.ID)).
+		Select(`questions.topic_id, topics.name, COUNT(*) AS answered,
+			SUM(CASE WHEN answers.is_correct THEN 1 ELSE 0 END) AS correct,
+			MAX(answers.answered_at) AS last_at,
+			DATE(answers.answered_at AT TIME ZONE ?) AS day`, tz).
+		Joins("JOIN questions ON questions.id = answers.question_id").
+		Joins("JOIN topics ON topics.id = questions.topic_id").
+		Group("questions.topic_id, topics.name, day").
+		Order("last_at DESC").
+		Limit(limit).
+		Scan(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load recent practice: %w", err)
+	}
+	for _, p := range sessions {
+		topicID := p.TopicID
+		activity = append(activity, Activity{
+			Type:              ActivityPractice,
+			Title:             p.Name,
+			TopicID:           &topicID,
+			QuestionsAnswered: p.Answered,
+			QuestionsCorrect:  p.Correct,
+			OccurredAt:        p.LastAt,
+		})
+	}
+
+	sort.SliceStable(activity, func(i, j int) bool {
+		return activity[i].OccurredAt.After(activity[j].OccurredAt)
+	})
+	if len(activity) > limit {
+		activity = activity[:limit]
+	}
+	return activity, nil
+}
+
+// weakTopics returns up to n attempted, unmastered topics, weakest first.
+// Ties go to the topic with the larger exam weight.
+func weakTopics(topics []TopicMastery, n int) []TopicMastery {
+	weak := make([]TopicMastery, 0)
+	for _, t := range topics {
+		if t.QuestionsAttempted >= weakTopicMinAttempts && !t.Mastered {
+			weak = append(weak, t)
+		}
+	}
+
+	sort.SliceStable(weak, func(i, j int) bool {
+		if weak[i].MasteryPercentage != weak[j].MasteryPercentage {
+			return weak[i].MasteryPercentage < weak[j].MasteryPercentage
+		}
+		return weak[i].Weight > weak[j].Weight
+	})
+	if n >= 0 && len(weak) > n {
+		weak = weak[:n]
+	}
+	return weak
+}
+
+// answers selects every graded answer of the user: practice answers and the
+// answered questions of completed tests, which are dated by the completion
+func answers(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return db.Raw(`
+		SELECT question_id, is_correct, created_at AS answered_at
+		FROM user_answers WHERE user_id = ?
+		UNION ALL
+		SELECT q.question_id, q.is_correct, t.completed_at
+		FROM practice_test_questions q JOIN practice_tests t ON t.id = q.practice_test_id
+		WHERE t.user_id = ? AND t.status = ? AND q.is_correct IS NOT NULL`,
+		userID, userID, "completed")
+}
+
+// bankQuery selects the active questions available in province
+func bankQuery(db *gorm.DB, province string) *gorm.DB {
+	query := db.Model(&models.Question{}).Where("questions.is_active = ?", true)
+	if province != "" {
+		query = query.Where("questions.province IS NULL OR questions.province = ?", province)
+	}
+	return query
+}
+
+func testTitle(testType string) string {
+	switch testType {
+	case "full_exam":
+		return "Full practice exam"
+	case "topic_specific":
+		return "Topic practice test"
+	default:
+		return "Custom practice test"
+	}
+}
+
+func percentage(part, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	p := part * 100 / total
+	if p > 100 {
+		p = 100
+	}
+	return p
+}
+
+func cacheKey(userID uuid.UUID) string {
+	return "dashboard:" + userID.String()
+}
@@ -0,0 +1,318 @@
+// Package deletion deletes accounts on request. A request only schedules
+// the deletion; the account keeps working through the grace period so the
+// user can change their mind, and the sweeper purges it afterwards.
+package deletion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/audit"
+	"github.com/nppe-pro/api/internal/email"
+	"github.com/nppe-pro/api/internal/lockout"
+	"github.com/nppe-pro/api/internal/media"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/storage"
+	"github.com/nppe-pro/api/pkg/tokens"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAlreadyScheduled = errors.New("account deletion is already scheduled")
+	ErrNotScheduled     = errors.New("account deletion is not scheduled")
+)
+
+// Service schedules, cancels and carries out account deletions
+type Service struct {
+	db     *gorm.DB
+	email  *email.Service
+	tokens *tokens.Store
+	store  storage.Store
+	media  *media.Service
+	config *config.Config
+}
+
+// NewService creates a new deletion service
+func NewService(db *gorm.DB, tokenStore *tokens.Store, store storage.Store, cfg *config.Config) *Service {
+	return &Service{
+		db:     db,
+		email:  email.NewService(db, cfg),
+		tokens: tokenStore,
+		store:  store,
+		media:  media.NewService(store, cfg),
+		config: cfg,
+	}
+}
+
+// Schedule marks the user's account for purging once the grace period is
+// over and emails them how to cancel
+func (s *Service) Schedule(ctx context.Context, user *models.User, actor audit.Actor) (time.Time, error) {
+	if user.DeletionDueAt != nil {
+		return *user.DeletionDueAt, ErrAlreadyScheduled
+	}
+
+	dueAt := time.Now().Add(s.config.Account.DeletionGracePeriod)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("deletion_due_at", dueAt).Error; err != nil {
+			return err
+		}
+		entry := actor.Entry(models.AuditDeletionRequest, "user", user.ID.String())
+		entry.After = map[string]interface{}{"deletion_due_at": dueAt}
+		if err := audit.Record(tx, entry); err != nil {
+			return err
+		}
+		return s.email.QueueDeletion(tx, user, dueAt)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	user.DeletionDueAt = &dueAt
+	return dueAt, nil
+}
+
+// Cancel keeps the account
+func (s *Service) Cancel(ctx context.Context, user *models.User, actor audit.Actor) error {
+	if user.DeletionDueAt == nil {
+		return ErrNotScheduled
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("deletion_due_at", nil).Error; err != nil {
+			return err
+		}
+		entry := actor.Entry(models.AuditDeletionCancel, "user", user.ID.String())
+		entry.Before = map[string]interface{}{"deletion_due_at": user.DeletionDueAt}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		return err
+	}
+	user.DeletionDueAt = nil
+	return nil
+}
+
+// Purge erases the personal data of a user whose grace period ended by
+// now. Study history goes, after the answers are added to QuestionStats;
+// forum posts stay, attributed to an anonymous account; payments stay for
+// accounting. The audit log gets a deletion certificate listing what was
+// done, which is all that remains of the request.
+func (s *Service) Purge(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	var avatarKey string
+	var exportKeys []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		// The user may have cancelled since the sweeper looked
+		if user.DeletionDueAt == nil || user.DeletionDueAt.After(now) {
+			return ErrNotScheduled
+		}
+		avatarKey = user.AvatarKey
+		dueAt := *user.DeletionDueAt
+
+		if err := tx.Model(&models.DataExport{}).
+			Where("user_id = ? AND storage_key <> ''", userID).
+			Pluck("storage_key", &exportKeys).Error; err != nil {
+			return err
+		}
+
+		answers, err := foldAnswers(tx, userID, now)
+		if err != nil {
+			return fmt.Errorf("failed to keep item statistics: %w", err)
+		}
+
+		removed, err := removeData(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		// The audit log keeps what was done, but not the email or IPs
+		scrubbed, err := audit.Scrub(tx, userID, strings.ToLower(user.Email), lockout.AccountPseudonym(s.config, user.Email))
+		if err != nil {
+			return fmt.Errorf("failed to scrub audit log: %w", err)
+		}
+
+		// Content others replied to or joined stays, under the anonymised owner
+		anonymised := map[string]interface{}{}
+		for _, owned := range []struct {
+			table  string
+			column string
+		}{
+			{"forum_posts", "user_id"},
+			{"forum_replies", "user_id"},
+			{"study_groups", "creator_id"},
+		} {
+			var n int64
+			if err := tx.Table(owned.table).Where(owned.column+" = ?", userID).Count(&n).Error; err != nil {
+				return err
+			}
+			anonymised[owned.table] = n
+		}
+
+		cancelled := tx.Model(&models.Subscription{}).
+			Where("user_id = ? AND status NOT IN ?", userID, []string{"cancelled", "expired"}).
+			Updates(map[string]interface{}{"status": "cancelled", "cancelled_at": now, "cancel_at_period_end": false})
+		if cancelled.Error != nil {
+			return cancelled.Error
+		}
+
+		var payments int64
+		if err := tx.Model(&models.Payment{}).Where("user_id = ?", userID).Count(&payments).Error; err != nil {
+			return err
+		}
+
+		// The row stays so forum posts and payments keep a valid owner, but
+		// nothing in it identifies the person any more
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":           "deleted-" + userID.String() + "@deleted.invalid",
+			"pending_email":   "",
+			"password_hash":   "",
+			"first_name":      "Deleted",
+			"last_name":       "User",
+			"province":        "",
+			"exam_date":       nil,
+			"is_verified":     false,
+			"avatar_url":      "",
+			"avatar_key":      "",
+			"study_streak":    0,
+			"longest_streak":  0,
+			"last_study_date": nil,
+			"timezone":        "",
+			"freezes_used":    0,
+			"freeze_month":    "",
+			"subscription_id": nil,
+			"o_auth_provider": "",
+			"o_auth_id":       "",
+			"mfa_secret":      "",
+			"mfa_enabled_at":  nil,
+			"mfa_last_step":   0,
+			"deletion_due_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+
+		entry := audit.Entry{Action: models.AuditAccountPurged, EntityType: "user", EntityID: userID.String()}
+		entry.Details = map[string]interface{}{
+			"registered_at":           user.CreatedAt,
+			"deletion_due_at":         dueAt,
+			"purged_at":               now,
+			"removed":                 removed,
+			"anonymised":              anonymised,
+			"answers_aggregated":      answers,
+			"audit_entries_scrubbed":  scrubbed,
+			"subscriptions_cancelled": cancelled.RowsAffected,
+			"payments_retained":       payments,
+		}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokens.RevokeAll(ctx, userID); err != nil {
+		log.Printf("⚠️ Failed to revoke sessions of purged user %s: %v", userID, err)
+	}
+	if avatarKey != "" {
+		if err := s.media.DeleteAvatar(ctx, avatarKey); err != nil {
+			log.Printf("⚠️ Failed to delete avatar of purged user %s: %v", userID, err)
+		}
+	}
+	for _, key := range exportKeys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("⚠️ Failed to delete data export of purged user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// foldAnswers adds the user's practice and test answers to QuestionStats
+// and returns how many there were
+func foldAnswers(tx *gorm.DB, userID uuid.UUID, now time.Time) (int64, error) {
+	result := tx.Exec(`
+		INSERT INTO question_stats (question_id, answers, correct, time_spent_seconds, updated_at)
+		SELECT question_id, COUNT(*), COUNT(*) FILTER (WHERE is_correct), COALESCE(SUM(time_spent_seconds), 0), ?
+		FROM (
+			SELECT question_id, is_correct, time_spent_seconds FROM user_answers WHERE user_id = ?
+			UNION ALL
+			SELECT q.question_id, q.is_correct, q.time_spent_seconds
+			FROM practice_test_questions q JOIN practice_tests t ON t.id = q.practice_test_id
+			WHERE t.user_id = ? AND q.is_correct IS NOT NULL
+		) a
+		GROUP BY question_id
+		ON CONFLICT (question_id) DO UPDATE SET
+			answers = question_stats.answers + EXCLUDED.answers,
+			correct = question_stats.correct + EXCLUDED.correct,
+			time_spent_seconds = question_stats.time_spent_seconds + EXCLUDED.time_spent_seconds,
+			updated_at = EXCLUDED.updated_at`,
+		now, userID, userID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	var answers int64
+	err := tx.Raw(`
+		SELECT (SELECT COUNT(*) FROM user_answers WHERE user_id = ?) +
+			(SELECT COUNT(*) FROM practice_test_questions q JOIN practice_tests t ON t.id = q.practice_test_id
+			 WHERE t.user_id = ? AND q.is_correct IS NOT NULL)`,
+		userID, userID).Scan(&answers).Error
+	return answers, err
+}
+
+// removeData deletes every row that only exists for the user and returns
+// the number deleted per table
+func removeData(tx *gorm.DB, userID uuid.UUID) (map[string]interface{}, error) {
+	removed := map[string]interface{}{}
+
+	// Members leave their groups first so the counts stay right
+	if err := tx.Exec(`
+		UPDATE study_groups SET member_count = GREATEST(member_count - 1, 0)
+		WHERE id IN (SELECT group_id FROM study_group_members WHERE user_id = ?)`, userID).Error; err != nil {
+		return nil, err
+	}
+
+	testIDs := tx.Model(&models.PracticeTest{}).Select("id").Where("user_id = ?", userID)
+	result := tx.Where("practice_test_id IN (?)", testIDs).Delete(&models.PracticeTestQuestion{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	removed["practice_test_questions"] = result.RowsAffected
+
+	for _, model := range []interface{}{
+		&models.PracticeTest{},
+		&models.UserAnswer{},
+		&models.UserBookmark{},
+		&models.BookmarkFolder{},
+		&models.UserTopicMastery{},
+		&models.UserModuleProgress{},
+		&models.StudyPath{},
+		&models.UserStats{},
+		&models.UserAchievement{},
+		&models.Notification{},
+		&models.UserNotificationSettings{},
+		&models.StudyGroupMember{},
+		&models.UserRole{},
+		&models.EmailVerification{},
+		&models.PasswordReset{},
+		&models.MFARecoveryCode{},
+		&models.OutboundEmail{},
+		&models.DataExport{},
+	} {
+		result := tx.Where("user_id = ?", userID).Delete(model)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		removed[result.Statement.Table] = result.RowsAffected
+	}
+	return removed, nil
+}
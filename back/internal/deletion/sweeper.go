@@ -0,0 +1,66 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/jobs"
+	"github.com/nppe-pro/api/internal/models"
+)
+
+// Sweeper purges accounts whose deletion grace period is over
+type Sweeper struct {
+	service *Service
+}
+
+// NewSweeper creates a sweeper backed by the given deletion service
+func NewSweeper(service *Service) *Sweeper {
+	return &Sweeper{service: service}
+}
+
+// Job returns the sweeper as a periodic background job
+func (s *Sweeper) Job() jobs.Job {
+	return jobs.Job{
+		Name:     "account_purge",
+		Interval: s.service.config.Account.PurgeInterval,
+		Run: func(ctx context.Context) error {
+			return s.Sweep(ctx, time.Now())
+		},
+	}
+}
+
+// Sweep purges every account due for deletion as of now. An account that
+// fails is logged and retried on the next run without holding up the rest.
+func (s *Sweeper) Sweep(ctx context.Context, now time.Time) error {
+	var due []uuid.UUID
+	if err := s.service.db.WithContext(ctx).Model(&models.User{}).
+		Where("deletion_due_at <= ?", now).
+		Pluck("id", &due).Error; err != nil {
+		return fmt.Errorf("failed to find accounts due for deletion: %w", err)
+	}
+
+	purged, failed := 0, 0
+	for _, id := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.service.Purge(ctx, id, now); err != nil {
+			if errors.Is(err, ErrNotScheduled) {
+				continue
+			}
+			log.Printf("⚠️ Failed to purge account %s: %v", id, err)
+			failed++
+			continue
+		}
+		purged++
+	}
+
+	if purged > 0 || failed > 0 {
+		log.Printf("🗑️ Account purge: deleted %d accounts, failed %d", purged, failed)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/jobs"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// dispatchBatch is the number of emails delivered per run
+	dispatchBatch = 50
+	// retryBase and retryMax bound the exponential backoff between attempts
+	retryBase = 30 * time.Second
+	retryMax  = 6 * time.Hour
+)
+
+// Dispatcher delivers queued emails
+type Dispatcher struct {
+	db     *gorm.DB
+	sender Sender
+	config *config.Config
+}
+
+// NewDispatcher creates a dispatcher delivering through sender
+func NewDispatcher(db *gorm.DB, sender Sender, cfg *config.Config) *Dispatcher {
+	return &Dispatcher{db: db, sender: sender, config: cfg}
+}
+
+// Job returns the dispatcher as a periodic background job
+func (d *Dispatcher) Job() jobs.Job {
+	return jobs.Job{
+		Name:     "email_dispatcher",
+		Interval: d.config.Email.DispatchInterval,
+		Run: func(ctx context.Context) error {
+			return d.Dispatch(ctx, time.Now())
+		},
+	}
+}
+
+// Dispatch delivers the emails due at now. Each email is claimed with
+// SKIP LOCKED so concurrent dispatchers never send one twice.
+func (d *Dispatcher) Dispatch(ctx context.Context, now time.Time) error {
+	sent, failed := 0, 0
+	for i := 0; i < dispatchBatch; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		delivered, found, err := d.dispatchOne(ctx, now)
+		if err != nil {
+			return err
+		}
+		if !found {
+			break
+		}
+		if delivered {
+			sent++
+		} else {
+			failed++
+		}
+	}
+
+	if sent > 0 || failed > 0 {
+		log.Printf("📧 Email dispatcher: sent %d, failed %d", sent, failed)
+	}
+	return nil
+}
+
+func (d *Dispatcher) dispatchOne(ctx context.Context, now time.Time) (delivered, found bool, err error) {
+	err = d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var msg models.OutboundEmail
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.EmailStatusPending, now).
+			Order("next_attempt_at ASC").
+			Limit(1).
+			Find(&msg)
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim email: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		found = true
+
+		sendErr := d.sender.Send(ctx, Message{
+			ToEmail: msg.ToEmail,
+			ToName:  msg.ToName,
+			Subject: msg.Subject,
+			HTML:    msg.HTMLBody,
+			Text:    msg.TextBody,
+		})
+
+		msg.Attempts++
+		if sendErr == nil {
+			delivered = true
+			sentAt := time.Now()
+			msg.Status = models.EmailStatusSent
+			msg.SentAt = &sentAt
+			msg.LastError = ""
+		} else {
+			msg.LastError = sendErr.Error()
+			if msg.Attempts >= d.config.Email.MaxAttempts {
+				msg.Status = models.EmailStatusFailed
+				log.Printf("⚠️ Giving up on email %s to %s after %d attempts: %v", msg.ID, msg.ToEmail, msg.Attempts, sendErr)
+			} else {
+				msg.NextAttemptAt = now.Add(backoff(msg.Attempts))
+			}
+		}
+		return tx.Save(&msg).Error
+	})
+	return delivered, found, err
+}
+
+// backoff returns the wait before the attempt after the given number of
+// failed attempts
+func backoff(attempts int) time.Duration {
+	wait := retryBase
+	for i := 1; i < attempts && wait < retryMax; i++ {
+		wait *= 2
+	}
+	if wait > retryMax {
+		wait = retryMax
+	}
+	return wait
+}
@@ -0,0 +1,270 @@
+// Package email renders and delivers transactional email.
+//
+// Messages are rendered from the templates in templates/ and written to the
+// outbound_emails table (the outbox), usually in the same transaction as
+// the change that caused them. The Dispatcher job delivers due messages
+// through the configured Sender and retries failures with exponential
+// backoff, so a mail outage never fails the request that queued the email.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+)
+
+// Message is a rendered email
+type Message struct {
+	ToEmail string
+	ToName  string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Sender delivers a message
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender returns the sender selected by EMAIL_PROVIDER
+func NewSender(cfg *config.Config) (Sender, error) {
+	c := cfg.Email
+	switch c.Provider {
+	case "sendgrid":
+		return &SendGridSender{config: c, client: &http.Client{Timeout: 15 * time.Second}}, nil
+	case "smtp":
+		return &SMTPSender{config: c}, nil
+	case "file":
+		if err := os.MkdirAll(c.FileDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create email directory: %w", err)
+		}
+		return &FileSender{config: c}, nil
+	case "log":
+		return &LogSender{}, nil
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", c.Provider)
+	}
+}
+
+// SendGridSender delivers through the SendGrid v3 API
+type SendGridSender struct {
+	config config.EmailConfig
+	client *http.Client
+}
+
+// Send implements Sender
+func (s *SendGridSender) Send(ctx context.Context, msg Message) error {
+	type address struct {
+		Email string `json:"email"`
+		Name  string `json:"name,omitempty"`
+	}
+	type content struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+	payload := map[string]interface{}{
+		"personalizations": []map[string]interface{}{
+			{"to": []address{{Email: msg.ToEmail, Name: msg.ToName}}},
+		},
+		"from":    address{Email: s.config.FromEmail, Name: s.config.FromName},
+		"subject": msg.Subject,
+		// SendGrid requires text/plain before text/html
+		"content": []content{
+			{Type: "text/plain", Value: msg.Text},
+			{Type: "text/html", Value: msg.HTML},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.SendGridURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.config.SendGridAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sendgrid request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("sendgrid returned %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// smtpTimeout bounds a whole SMTP delivery, from dialing to QUIT, unless
+// the context's deadline is sooner
+const smtpTimeout = 30 * time.Second
+
+// SMTPSender delivers through an SMTP relay, using STARTTLS when offered
+type SMTPSender struct {
+	config config.EmailConfig
+}
+
+// Send implements Sender. It follows smtp.SendMail, but dials with a
+// deadline and gives up when ctx is cancelled, so a relay that stops
+// answering cannot hold the dispatcher.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	raw, err := buildMIME(s.config, msg)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	addr := net.JoinHostPort(s.config.SMTPHost, strconv.Itoa(s.config.SMTPPort))
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp dial failed: %w", err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, s.config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.SMTPHost}); err != nil {
+			return fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+	if s.config.SMTPUsername != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server does not support AUTH")
+		}
+		auth := smtp.PlainAuth("", s.config.SMTPUsername, s.config.SMTPPassword, s.config.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.config.FromEmail); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.ToEmail); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// FileSender writes each message as an .eml file, for development and tests
+type FileSender struct {
+	config config.EmailConfig
+}
+
+// Send implements Sender
+func (s *FileSender) Send(_ context.Context, msg Message) error {
+	raw, err := buildMIME(s.config, msg)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewString()[:8])
+	return os.WriteFile(filepath.Join(s.config.FileDir, name), raw, 0o644)
+}
+
+// LogSender logs messages instead of delivering them
+type LogSender struct{}
+
+// Send implements Sender
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	log.Printf("📧 Email to %s: %s\n%s", msg.ToEmail, msg.Subject, msg.Text)
+	return nil
+}
+
+// buildMIME encodes msg as a multipart/alternative message
+func buildMIME(c config.EmailConfig, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	from := c.FromEmail
+	if c.FromName != "" {
+		from = mime.QEncoding.Encode("utf-8", c.FromName) + " <" + c.FromEmail + ">"
+	}
+	to := msg.ToEmail
+	if msg.ToName != "" {
+		to = mime.QEncoding.Encode("utf-8", msg.ToName) + " <" + msg.ToEmail + ">"
+	}
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", uuid.NewString(), domainOf(c.FromEmail))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func domainOf(address string) string {
+	for i := len(address) - 1; i >= 0; i-- {
+		if address[i] == '@' {
+			return address[i+1:]
+		}
+	}
+	return "localhost"
+}
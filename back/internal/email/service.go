@@ -0,0 +1,155 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Service renders emails and queues them in the outbox
+type Service struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewService creates a new email service
+func NewService(db *gorm.DB, cfg *config.Config) *Service {
+	return &Service{db: db, config: cfg}
+}
+
+// Queue renders a template for user and adds it to the outbox. Pass the
+// transaction that makes the change the email is about as tx so that both
+// commit or roll back together; nil uses the service's connection.
+func (s *Service) Queue(tx *gorm.DB, user *models.User, template string, data interface{}) error {
+	if tx == nil {
+		tx = s.db
+	}
+
+	msg, err := Render(template, data)
+	if err != nil {
+		return err
+	}
+
+	userID := user.ID
+	return tx.Create(&models.OutboundEmail{
+		UserID:        &userID,
+		ToEmail:       user.Email,
+		ToName:        strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Template:      template,
+		Subject:       msg.Subject,
+		HTMLBody:      msg.HTML,
+		TextBody:      msg.Text,
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// QueueVerification queues the email verification link
+func (s *Service) QueueVerification(tx *gorm.DB, user *models.User, v *models.EmailVerification) error {
+	return s.Queue(tx, user, TemplateVerification, VerificationData{
+		Common:    s.common(user),
+		Link:      s.link("/verify-email/" + v.Token),
+		ExpiresIn: humanDuration(time.Until(v.ExpiresAt)),
+	})
+}
+
+// QueuePasswordReset queues the password reset link
+func (s *Service) QueuePasswordReset(tx *gorm.DB, user *models.User, r *models.PasswordReset) error {
+	return s.Queue(tx, user, TemplatePasswordReset, PasswordResetData{
+		Common:    s.common(user),
+		Link:      s.link("/reset-password/" + r.Token),
+		ExpiresIn: humanDuration(time.Until(r.ExpiresAt)),
+	})
+}
+
+// QueueEmailChange queues the link confirming a new email address. It is
+// sent to the new address, not the current one.
+func (s *Service) QueueEmailChange(tx *gorm.DB, user *models.User, v *models.EmailVerification) error {
+	recipient := *user
+	recipient.Email = v.Email
+	return s.Queue(tx, &recipient, TemplateEmailChange, EmailChangeData{
+		Common:    s.common(user),
+		NewEmail:  v.Email,
+		Link:      s.link("/verify-email/" + v.Token),
+		ExpiresIn: humanDuration(time.Until(v.ExpiresAt)),
+	})
+}
+
+// QueueEmailNotice tells the current address that a change to newEmail
+// was requested, so a change the user did not make does not go unnoticed
+func (s *Service) QueueEmailNotice(tx *gorm.DB, user *models.User, newEmail string) error {
+	return s.Queue(tx, user, TemplateEmailNotice, EmailNoticeData{
+		Common:   s.common(user),
+		NewEmail: newEmail,
+		Link:     s.link("/settings/account"),
+	})
+}
+
+// QueueDeletion confirms a deletion request and says how to cancel it
+// before the account is purged at dueAt
+func (s *Service) QueueDeletion(tx *gorm.DB, user *models.User, dueAt time.Time) error {
+	return s.Queue(tx, user, TemplateDeletion, DeletionData{
+		Common:  s.common(user),
+		DueDate: dueAt.UTC().Format("January 2, 2006"),
+		Link:    s.link("/settings/account"),
+	})
+}
+
+// QueueDataExport says a personal data export is ready to download until
+// expiresAt
+func (s *Service) QueueDataExport(tx *gorm.DB, user *models.User, expiresAt time.Time) error {
+	return s.Queue(tx, user, TemplateDataExport, DataExportData{
+		Common:     s.common(user),
+		ExpiryDate: expiresAt.UTC().Format("January 2, 2006"),
+		Link:       s.link("/settings/privacy"),
+	})
+}
+
+// QueueWeeklyReport queues a weekly progress report
+func (s *Service) QueueWeeklyReport(tx *gorm.DB, user *models.User, data WeeklyReportData) error {
+	data.Common = s.common(user)
+	if data.Link == "" {
+		data.Link = s.link("/dashboard")
+	}
+	return s.Queue(tx, user, TemplateWeeklyReport, data)
+}
+
+// QueueReminder queues a study reminder
+func (s *Service) QueueReminder(tx *gorm.DB, user *models.User, data ReminderData) error {
+	data.Common = s.common(user)
+	if data.Title == "" {
+		data.Title = "Time for today's study session"
+	}
+	if data.Link == "" {
+		data.Link = s.link("/practice")
+	}
+	return s.Queue(tx, user, TemplateReminder, data)
+}
+
+func (s *Service) common(user *models.User) Common {
+	return Common{AppName: s.config.Email.FromName, Name: user.FirstName}
+}
+
+func (s *Service) link(path string) string {
+	return strings.TrimRight(s.config.Server.FrontendURL, "/") + path
+}
+
+// humanDuration formats d as "24 hours" or "1 hour", rounding up
+func humanDuration(d time.Duration) string {
+	if d < time.Hour {
+		minutes := int((d + time.Minute - 1) / time.Minute)
+		return plural(minutes, "minute")
+	}
+	return plural(int((d+time.Hour-1)/time.Hour), "hour")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,147 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template names
+const (
+	TemplateVerification  = "verification"
+	TemplatePasswordReset = "password_reset"
+	TemplateWeeklyReport  = "weekly_report"
+	TemplateReminder      = "reminder"
+	TemplateEmailChange   = "email_change"
+	TemplateEmailNotice   = "email_change_notice"
+	TemplateDeletion      = "account_deletion"
+	TemplateDataExport    = "data_export"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Common holds the fields every template uses. It is filled in by the
+// Service.
+type Common struct {
+	AppName string
+	Name    string
+}
+
+// VerificationData renders TemplateVerification
+type VerificationData struct {
+	Common
+	Link      string
+	ExpiresIn string
+}
+
+// PasswordResetData renders TemplatePasswordReset
+type PasswordResetData struct {
+	Common
+	Link      string
+	ExpiresIn string
+}
+
+// EmailChangeData renders TemplateEmailChange
+type EmailChangeData struct {
+	Common
+	NewEmail  string
+	Link      string
+	ExpiresIn string
+}
+
+// EmailNoticeData renders TemplateEmailNotice
+type EmailNoticeData struct {
+	Common
+	NewEmail string
+	Link     string
+}
+
+// DeletionData renders TemplateDeletion
+type DeletionData struct {
+	Common
+	DueDate string
+	Link    string
+}
+
+// DataExportData renders TemplateDataExport
+type DataExportData struct {
+	Common
+	ExpiryDate string
+	Link       string
+}
+
+// WeeklyReportData renders TemplateWeeklyReport
+type WeeklyReportData struct {
+	Common
+	QuestionsAnswered int
+	Accuracy          float64
+	TestsCompleted    int
+	StudyStreak       int
+	PassProbability   float64
+	WeakTopics        []string
+	Link              string
+}
+
+// ReminderData renders TemplateReminder
+type ReminderData struct {
+	Common
+	Title       string
+	Message     string
+	StudyStreak int
+	Link        string
+}
+
+type templatePair struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var templates = mustParseTemplates(
+	TemplateVerification,
+	TemplatePasswordReset,
+	TemplateWeeklyReport,
+	TemplateReminder,
+	TemplateEmailChange,
+	TemplateEmailNotice,
+	TemplateDeletion,
+	TemplateDataExport,
+)
+
+func mustParseTemplates(names ...string) map[string]templatePair {
+	parsed := make(map[string]templatePair, len(names))
+	for _, name := range names {
+		html := htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html"))
+		text := texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/layout.txt", "templates/"+name+".txt"))
+		parsed[name] = templatePair{html: html, text: text}
+	}
+	return parsed
+}
+
+// Render renders the named template into a message without recipient
+func Render(name string, data interface{}) (Message, error) {
+	t, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, html, text bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+	if err := t.text.ExecuteTemplate(&text, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
@@ -0,0 +1,133 @@
+// Package events is a small in-process publish/subscribe bus for domain
+// events such as a completed test or a mastered topic. Events are published
+// after the originating transaction commits, so subscribers always see the
+// committed state.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Type identifies a kind of domain event
+type Type string
+
+const (
+	// AnswerRecorded is published after a practice answer is stored
+	AnswerRecorded Type = "answer.recorded"
+	// TestCompleted is published after a practice test is scored
+	TestCompleted Type = "test.completed"
+	// TopicMastered is published when a topic's mastery first reaches the
+	// mastered threshold
+	TopicMastered Type = "topic.mastered"
+	// ModuleProgressed is published when a user records study module progress
+	ModuleProgressed Type = "module.progressed"
+	// StreakExtended is published when a study streak grows by a day
+	StreakExtended Type = "streak.extended"
+	// StreakMilestone is published when a streak reaches a configured length
+	StreakMilestone Type = "streak.milestone"
+	// StreakBroken is published when a streak lapses
+	StreakBroken Type = "streak.broken"
+	// ProfileUpdated is published when a user changes their profile
+	ProfileUpdated Type = "profile.updated"
+)
+
+// Event is a domain event. Payload holds one of the *Payload types below,
+// matching Type.
+type Event struct {
+	Type       Type
+	UserID     uuid.UUID
+	OccurredAt time.Time
+	Payload    interface{}
+}
+
+// AnswerRecordedPayload accompanies AnswerRecorded
+type AnswerRecordedPayload struct {
+	AnswerID   uuid.UUID
+	QuestionID uuid.UUID
+	TopicID    uuid.UUID
+	Correct    bool
+}
+
+// TestCompletedPayload accompanies TestCompleted
+type TestCompletedPayload struct {
+	TestID        uuid.UUID
+	TestType      string
+	Score         float64
+	StartedAt     time.Time
+	AutoSubmitted bool
+}
+
+// ModuleProgressedPayload accompanies ModuleProgressed
+type ModuleProgressedPayload struct {
+	ModuleID  uuid.UUID
+	Progress  int
+	Completed bool
+}
+
+// TopicMasteredPayload accompanies TopicMastered
+type TopicMasteredPayload struct {
+	TopicID           uuid.UUID
+	MasteryPercentage float64
+}
+
+// StreakPayload accompanies the streak events. For StreakBroken, Days is
+// the length of the streak that lapsed.
+type StreakPayload struct {
+	Days        int
+	Longest     int
+	FreezesUsed int
+}
+
+// ProfileUpdatedPayload accompanies ProfileUpdated. Fields lists the
+// profile fields that changed, by their JSON names.
+type ProfileUpdatedPayload struct {
+	Fields []string
+}
+
+// Handler reacts to an event
+type Handler func(ctx context.Context, e Event) error
+
+// Bus dispatches events to their subscribers
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Type][]Handler)}
+}
+
+// Subscribe registers h for events of type t
+func (b *Bus) Subscribe(t Type, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[t] = append(b.handlers[t], h)
+}
+
+// Publish delivers e to every subscriber in registration order. Delivery is
+// synchronous; a failing subscriber is logged and does not stop the others.
+// Publishing on a nil bus is a no-op.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := append([]Handler(nil), b.handlers[e.Type]...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, e); err != nil {
+			log.Printf("⚠️ Event %s for user %s: %v", e.Type, e.UserID, err)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+// Package exam owns the lifecycle of a practice test once it has started:
+// the server-side clock and the scoring path shared by manual submission
+// and auto-submission.
+package exam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/grading"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTestNotFound      = errors.New("test not found")
+	ErrTestNotInProgress = errors.New("test is not in progress")
+	ErrTimeExpired       = errors.New("test time limit exceeded")
+)
+
+// Service scores and times practice tests
+type Service struct {
+	db     *gorm.DB
+	config *config.Config
+	bus    *events.Bus
+}
+
+// NewService creates a new exam service. Completed tests are announced on
+// bus, which may be nil.
+func NewService(db *gorm.DB, cfg *config.Config, bus *events.Bus) *Service {
+	return &Service{db: db, config: cfg, bus: bus}
+}
+
+// TopicScore is the per-topic outcome of a completed test
+type TopicScore struct {
+	TopicID uuid.UUID
+	Name    string
+	Correct int
+	Credit  float64
+	Total   int
+}
+
+// Percentage returns the credit earned in the topic as a percentage. Under
+// all-or-nothing scoring this is the share of correct answers.
+func (t TopicScore) Percentage() float64 {
+	if t.Total == 0 {
+		return 0
+	}
+	return t.Credit / float64(t.Total) * 100
+}
+
+// Result is the outcome of completing a test
+type Result struct {
+	Test   *models.PracticeTest
+	Topics []TopicScore
+}
+
+// Deadline returns when the test's time runs out. Untimed tests have none.
+func (s *Service) Deadline(test *models.PracticeTest) (time.Time, bool) {
+	if test.TimeLimitMinutes <= 0 {
+		return time.Time{}, false
+	}
+	return test.StartedAt.Add(time.Duration(test.TimeLimitMinutes) * time.Minute), true
+}
+
+// RemainingSeconds returns the seconds left before the deadline, or nil for
+// untimed tests
+func (s *Service) RemainingSeconds(test *models.PracticeTest, now time.Time) *int {
+	deadline, ok := s.Deadline(test)
+	if !ok {
+		return nil
+	}
+	remaining := int(deadline.Sub(now).Seconds())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+// Expired reports whether the deadline plus the grace period has passed.
+// The grace period absorbs network latency on the final answer.
+func (s *Service) Expired(test *models.PracticeTest, now time.Time) bool {
+	deadline, ok := s.Deadline(test)
+	if !ok {
+		return false
+	}
+	return now.After(deadline.Add(s.config.Exam.GracePeriod))
+}
+
+// CheckAnswerable reports whether answers may still be recorded on the test
+func (s *Service) CheckAnswerable(test *models.PracticeTest, now time.Time) error {
+	if test.Status != "in_progress" {
+		return ErrTestNotInProgress
+	}
+	if s.Expired(test, now) {
+		return ErrTimeExpired
+	}
+	return nil
+}
+
+// ElapsedSeconds returns the time spent on the test measured by the server,
+// capped at the time limit
+func (s *Service) ElapsedSeconds(test *models.PracticeTest, now time.Time) int {
+	elapsed := now.Sub(test.StartedAt)
+	if deadline, ok := s.Deadline(test); ok && now.After(deadline) {
+		elapsed = deadline.Sub(test.StartedAt)
+	}
+	if elapsed < 0 {
+		return 0
+	}
+	return int(elapsed.Seconds())
+}
+
+// SaveAnswer records an answer on a test question while holding the lock
+// on its test, so the answer cannot land after the test was completed or
+// its time ran out
+func (s *Service) SaveAnswer(ctx context.Context, tq *models.PracticeTestQuestion, now time.Time) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		test, err := lockTest(tx, tq.PracticeTestID)
+		if err != nil {
+			return err
+		}
+		if err := s.CheckAnswerable(test, now); err != nil {
+			return err
+		}
+		return tx.Omit("Question").Save(tq).Error
+	})
+}
+
+// Complete scores an in-progress test submitted by its user and updates the
+// user's statistics in a single transaction. Every answer is graded again
+// against the current answer key using the configured multi-select mode.
+// A test whose time has run out is refused with ErrTimeExpired and must be
+// ended through Expire instead.
+func (s *Service) Complete(ctx context.Context, testID uuid.UUID, now time.Time) (*Result, error) {
+	var result *Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		test, err := lockTest(tx, testID)
+		if err != nil {
+			return err
+		}
+		if err := s.CheckAnswerable(test, now); err != nil {
+			return err
+		}
+		result, err = s.complete(tx, test, now, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, result, now)
+	return result, nil
+}
+
+// Expire ends an in-progress test whose time has run out: it is
+// auto-submitted with the answers recorded so far, or abandoned when
+// nothing was answered. The sweeper and the handlers that notice an
+// expired test all end it here, so the outcome does not depend on which
+// got there first. Abandoned tests come back with no topics.
+func (s *Service) Expire(ctx context.Context, testID uuid.UUID, now time.Time) (*Result, error) {
+	var result *Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		test, err := lockTest(tx, testID)
+		if err != nil {
+			return err
+		}
+		if test.Status != "in_progress" {
+			return ErrTestNotInProgress
+		}
+
+		var answered int64
+		if err := tx.Model(&models.PracticeTestQuestion{}).
+			Where("practice_test_id = ? AND cardinality(selected_option_ids) > 0", test.ID).
+			Count(&answered).Error; err != nil {
+			return fmt.Errorf("failed to count answers: %w", err)
+		}
+		if answered == 0 {
+			if err := tx.Model(test).Update("status", "abandoned").Error; err != nil {
+				return fmt.Errorf("failed to abandon test: %w", err)
+			}
+			test.Status = "abandoned"
+			result = &Result{Test: test}
+			return nil
+		}
+
+		result, err = s.complete(tx, test, now, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Test.Status == "completed" {
+		s.publish(ctx, result, now)
+	}
+	return result, nil
+}
+
+// lockTest loads a test and locks its row for the rest of tx
+func lockTest(tx *gorm.DB, testID uuid.UUID) (*models.PracticeTest, error) {
+	var test models.PracticeTest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&test, "id = ?", testID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTestNotFound
+		}
+		return nil, fmt.Errorf("failed to load test: %w", err)
+	}
+	return &test, nil
+}
+
+// complete scores a test locked by tx
+func (s *Service) complete(tx *gorm.DB, test *models.PracticeTest, now time.Time, auto bool) (*Result, error) {
+	if err := tx.Where("practice_test_id = ?", test.ID).
+		Preload("Question.Topic").
+		Preload("Question.Options").
+		Find(&test.Questions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load test questions: %w", err)
+	}
+
+	// Calculate score and topic performance
+	mode := s.config.Exam.MultiSelectMode
+	correctCount := 0
+	credit := 0.0
+	topicIndex := make(map[uuid.UUID]int)
+	topics := make([]TopicScore, 0)
+
+	for i := range test.Questions {
+		tq := &test.Questions[i]
+		var outcome grading.Outcome
+		if tq.Question != nil && len(tq.SelectedOptionIDs) > 0 {
+			outcome = grading.Grade(tq.Question, tq.SelectedOptionIDs, mode)
+			correct := outcome.Correct
+			tq.IsCorrect = &correct
+		}
+		tq.Credit = outcome.Credit
+
+		if err := tx.Model(tq).Updates(map[string]interface{}{
+			"is_correct": tq.IsCorrect,
+			"credit":     tq.Credit,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("failed to grade test question: %w", err)
+		}
+
+		correct := outcome.Correct
+		if correct {
+			correctCount++
+		}
+		credit += outcome.Credit
+
+		if tq.Question != nil && tq.Question.Topic != nil {
+			idx, ok := topicIndex[tq.Question.TopicID]
+			if !ok {
+				idx = len(topics)
+				topicIndex[tq.Question.TopicID] = idx
+				topics = append(topics, TopicScore{
+					TopicID: tq.Question.TopicID,
+					Name:    tq.Question.Topic.Name,
+				})
+			}
+			topics[idx].Total++
+			topics[idx].Credit += outcome.Credit
+			if correct {
+				topics[idx].Correct++
+			}
+		}
+	}
+
+	score := float64(0)
+	if test.TotalQuestions > 0 {
+		score = (credit / float64(test.TotalQuestions)) * 100
+	}
+
+	completedAt := now
+	test.Status = "completed"
+	test.CorrectAnswers = correctCount
+	test.Score = score
+	test.TimeSpentSeconds = s.ElapsedSeconds(test, now)
+	test.CompletedAt = &completedAt
+	test.AutoSubmitted = auto
+	test.ScoringMode = mode
+
+	if err := tx.Omit("Questions").Save(test).Error; err != nil {
+		return nil, fmt.Errorf("failed to complete test: %w", err)
+	}
+
+	if err := s.updateUserStats(tx, test, now); err != nil {
+		return nil, err
+	}
+
+	return &Result{Test: test, Topics: topics}, nil
+}
+
+// publish announces a completed test
+func (s *Service) publish(ctx context.Context, result *Result, now time.Time) {
+	s.bus.Publish(ctx, events.Event{
+		Type:       events.TestCompleted,
+		UserID:     result.Test.UserID,
+		OccurredAt: now,
+		Payload: events.TestCompletedPayload{
+			TestID:        result.Test.ID,
+			TestType:      result.Test.TestType,
+			Score:         result.Test.Score,
+			StartedAt:     result.Test.StartedAt,
+			AutoSubmitted: result.Test.AutoSubmitted,
+		},
+	})
+}
+
+// updateUserStats counts a completed test into the user's totals. The
+// answered and correct questions add to the same counters as practice
+// answers, which the dashboard accuracy and the question achievements read.
+func (s *Service) updateUserStats(tx *gorm.DB, test *models.PracticeTest, now time.Time) error {
+	answered := 0
+	for _, tq := range test.Questions {
+		if tq.IsCorrect != nil {
+			answered++
+		}
+	}
+
+	var average float64
+	if err := tx.Model(&models.PracticeTest{}).
+		Where("user_id = ? AND status = ?", test.UserID, "completed").
+		Select("COALESCE(AVG(score), 0)").
+		Scan(&average).Error; err != nil {
+		return fmt.Errorf("failed to average test scores: %w", err)
+	}
+
+	// Increment in place so a practice answer saved meanwhile is not lost
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserStats{UserID: test.UserID}).Error; err != nil {
+		return fmt.Errorf("failed to create user stats: %w", err)
+	}
+	if err := tx.Model(&models.UserStats{}).
+		Where("user_id = ?", test.UserID).
+		Updates(map[string]interface{}{
+			"questions_completed":  gorm.Expr("questions_completed + ?", answered),
+			"questions_correct":    gorm.Expr("questions_correct + ?", test.CorrectAnswers),
+			"practice_tests_taken": gorm.Expr("practice_tests_taken + 1"),
+			"average_test_score":   average,
+			"time_studied_seconds": gorm.Expr("time_studied_seconds + ?", test.TimeSpentSeconds),
+			"updated_at":           now,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update user stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package exam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/jobs"
+	"github.com/nppe-pro/api/internal/models"
+)
+
+// Sweeper closes in-progress tests the candidate never submitted. Timed
+// tests past their deadline are ended through Service.Expire; untimed tests
+// are abandoned once they have been idle for the configured period.
+type Sweeper struct {
+	service *Service
+}
+
+// NewSweeper creates a sweeper backed by the given exam service
+func NewSweeper(service *Service) *Sweeper {
+	return &Sweeper{service: service}
+}
+
+// Job returns the sweeper as a periodic background job
+func (s *Sweeper) Job() jobs.Job {
+	return jobs.Job{
+		Name:     "exam_sweeper",
+		Interval: s.service.config.Exam.SweepInterval,
+		Run: func(ctx context.Context) error {
+			return s.Sweep(ctx, time.Now())
+		},
+	}
+}
+
+// Sweep closes every test that has expired or gone stale as of now
+func (s *Sweeper) Sweep(ctx context.Context, now time.Time) error {
+	cfg := s.service.config.Exam
+	db := s.service.db.WithContext(ctx)
+
+	var expired []uuid.UUID
+	if err := db.Model(&models.PracticeTest{}).
+		Where("status = ? AND time_limit_minutes > 0", "in_progress").
+		Where("started_at + time_limit_minutes * INTERVAL '1 minute' < ?", now.Add(-cfg.GracePeriod)).
+		Pluck("id", &expired).Error; err != nil {
+		return fmt.Errorf("failed to find expired tests: %w", err)
+	}
+
+	completed, abandoned := 0, 0
+	for _, id := range expired {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		result, err := s.service.Expire(ctx, id, now)
+		if err != nil {
+			// The candidate may have submitted between the query and now
+			if errors.Is(err, ErrTestNotInProgress) || errors.Is(err, ErrTestNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to expire test %s: %w", id, err)
+		}
+		if result.Test.Status == "completed" {
+			completed++
+		} else {
+			abandoned++
+		}
+	}
+
+	idleSince := now.Add(-cfg.AbandonAfter)
+	result := db.Model(&models.PracticeTest{}).
+		Where("status = ? AND COALESCE(time_limit_minutes, 0) <= 0", "in_progress").
+		Where("started_at < ?", idleSince).
+		Where("NOT EXISTS (SELECT 1 FROM practice_test_questions q WHERE q.practice_test_id = practice_tests.id AND q.updated_at >= ?)", idleSince).
+		Update("status", "abandoned")
+	if result.Error != nil {
+		return fmt.Errorf("failed to abandon stale tests: %w", result.Error)
+	}
+	abandoned += int(result.RowsAffected)
+
+	if completed > 0 || abandoned > 0 {
+		log.Printf("⏱️ Exam sweeper: auto-submitted %d, abandoned %d", completed, abandoned)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+)
+
+const readme = `This archive holds the personal data we store about your account.
+
+profile.json        Your profile, statistics, settings and subscription
+answers.csv         Every practice question you answered
+practice_tests.json Your practice tests with each question and answer
+bookmarks.csv       The questions you bookmarked, with their folders and notes
+forum.json          Your forum posts and replies
+notifications.csv   Notifications we sent you in the app
+payments.csv        Your payments (amounts in cents)
+progress.json       Topic mastery, study module progress and achievements
+
+Times are in UTC. Questions are referred to by ID.
+`
+
+// archive writes the files of one user's export into a ZIP
+type archive struct {
+	db     *gorm.DB
+	zw     *zip.Writer
+	userID uuid.UUID
+}
+
+// writeArchive writes the export of user to w
+func writeArchive(ctx context.Context, db *gorm.DB, user *models.User, w io.Writer) error {
+	a := &archive{db: db.WithContext(ctx), zw: zip.NewWriter(w), userID: user.ID}
+
+	if err := a.text("README.txt", readme); err != nil {
+		return err
+	}
+	for _, write := range []func() error{
+		func() error { return a.profile(user) },
+		a.answers,
+		a.practiceTests,
+		a.bookmarks,
+		a.forum,
+		a.notifications,
+		a.payments,
+		a.progress,
+	} {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := write(); err != nil {
+			return err
+		}
+	}
+	return a.zw.Close()
+}
+
+func (a *archive) profile(user *models.User) error {
+	var stats models.UserStats
+	if err := a.db.Where("user_id = ?", a.userID).Limit(1).Find(&stats).Error; err != nil {
+		return err
+	}
+	var settings []models.UserNotificationSettings
+	if err := a.db.Where("user_id = ?", a.userID).Find(&settings).Error; err != nil {
+		return err
+	}
+	var subscriptions []models.Subscription
+	if err := a.db.Where("user_id = ?", a.userID).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+	var paths []models.StudyPath
+	if err := a.db.Where("user_id = ?", a.userID).Find(&paths).Error; err != nil {
+		return err
+	}
+
+	return a.json("profile.json", map[string]interface{}{
+		"exported_at": time.Now().UTC(),
+		"user":        user,
+		"stats": map[string]interface{}{
+			"questions_completed":  stats.QuestionsCompleted,
+			"questions_correct":    stats.QuestionsCorrect,
+			"practice_tests_taken": stats.PracticeTestsTaken,
+			"average_test_score":   stats.AverageTestScore,
+			"time_studied_seconds": stats.TimeStudiedSeconds,
+		},
+		"notification_settings": settings,
+		"subscriptions":         subscriptions,
+		"study_paths":           paths,
+	})
+}
+
+func (a *archive) answers() error {
+	query := a.db.Model(&models.UserAnswer{}).Where("user_id = ?", a.userID).Order("created_at ASC")
+	return csvFile(a, "answers.csv",
+		[]string{"answered_at", "question_id", "selected_option_ids", "is_correct", "time_spent_seconds"},
+		query, func(answer *models.UserAnswer) []string {
+			selected := make([]string, len(answer.SelectedOptionIDs))
+			for i, id := range answer.SelectedOptionIDs {
+				selected[i] = id.String()
+			}
+			return []string{
+				formatTime(answer.CreatedAt),
+				answer.QuestionID.String(),
+				strings.Join(selected, " "),
+				strconv.FormatBool(answer.IsCorrect),
+				strconv.Itoa(answer.TimeSpentSeconds),
+			}
+		})
+}
+
+func (a *archive) practiceTests() error {
+	var tests []models.PracticeTest
+	if err := a.db.Where("user_id = ?", a.userID).
+		Preload("Questions", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Order("started_at ASC").
+		Find(&tests).Error; err != nil {
+		return err
+	}
+	return a.json("practice_tests.json", tests)
+}
+
+// bookmarkRow is a bookmark with the name of its folder
+type bookmarkRow struct {
+	CreatedAt  time.Time
+	QuestionID uuid.UUID
+	Folder     string
+	Note       string
+}
+
+func (a *archive) bookmarks() error {
+	query := a.db.Table("user_bookmarks b").
+		Select("b.created_at, b.question_id, COALESCE(f.name, '') AS folder, b.note").
+		Joins("LEFT JOIN bookmark_folders f ON f.id = b.folder_id").
+		Where("b.user_id = ?", a.userID).
+		Order("b.created_at ASC")
+	return csvFile(a, "bookmarks.csv", []string{"bookmarked_at", "question_id", "folder", "note"},
+		query, func(bookmark *bookmarkRow) []string {
+			return []string{formatTime(bookmark.CreatedAt), bookmark.QuestionID.String(), bookmark.Folder, bookmark.Note}
+		})
+}
+
+func (a *archive) forum() error {
+	var posts []models.ForumPost
+	if err := a.db.Where("user_id = ?", a.userID).Order("created_at ASC").Find(&posts).Error; err != nil {
+		return err
+	}
+	var replies []models.ForumReply
+	if err := a.db.Where("user_id = ?", a.userID).Order("created_at ASC").Find(&replies).Error; err != nil {
+		return err
+	}
+	return a.json("forum.json", map[string]interface{}{"posts": posts, "replies": replies})
+}
+
+func (a *archive) notifications() error {
+	query := a.db.Model(&models.Notification{}).Where("user_id = ?", a.userID).Order("created_at ASC")
+	return csvFile(a, "notifications.csv", []string{"created_at", "type", "title", "message", "link", "is_read"},
+		query, func(n *models.Notification) []string {
+			return []string{formatTime(n.CreatedAt), n.Type, n.Title, n.Message, n.Link, strconv.FormatBool(n.IsRead)}
+		})
+}
+
+func (a *archive) payments() error {
+	query := a.db.Model(&models.Payment{}).Where("user_id = ?", a.userID).Order("created_at ASC")
+	return csvFile(a, "payments.csv",
+		[]string{"created_at", "amount_cents", "currency", "status", "description", "subscription_id", "stripe_payment_id"},
+		query, func(p *models.Payment) []string {
+			return []string{
+				formatTime(p.CreatedAt),
+				strconv.Itoa(p.Amount),
+				p.Currency,
+				p.Status,
+				p.Description,
+				p.SubscriptionID.String(),
+				p.StripePaymentID,
+			}
+		})
+}
+
+func (a *archive) progress() error {
+	var mastery []models.UserTopicMastery
+	if err := a.db.Where("user_id = ?", a.userID).Find(&mastery).Error; err != nil {
+		return err
+	}
+	var modules []models.UserModuleProgress
+	if err := a.db.Where("user_id = ?", a.userID).Find(&modules).Error; err != nil {
+		return err
+	}
+	var achievements []models.UserAchievement
+	if err := a.db.Where("user_id = ?", a.userID).Find(&achievements).Error; err != nil {
+		return err
+	}
+	return a.json("progress.json", map[string]interface{}{
+		"topic_mastery":   mastery,
+		"module_progress": modules,
+		"achievements":    achievements,
+	})
+}
+
+func (a *archive) text(name, content string) error {
+	f, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+func (a *archive) json(name string, v interface{}) error {
+	f, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// csvFile streams the rows of query into a CSV file, one record per row,
+// so long histories are never held in memory
+func csvFile[T any](a *archive, name string, header []string, query *gorm.DB, record func(*T) []string) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	f, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write(header)
+	for rows.Next() {
+		var row T
+		if err := a.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		w.Write(record(&row))
+	}
+	w.Flush()
+	return errors.Join(rows.Err(), w.Error())
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,107 @@
+// Package export builds personal data exports: a ZIP of JSON and CSV files
+// holding everything stored about a user, for access requests under PIPEDA
+// and the GDPR. Exports are requested through Service and built in the
+// background by Worker.
+package export
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/email"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInProgress = errors.New("an export is already in progress")
+	ErrNotFound   = errors.New("export not found")
+	ErrNotReady   = errors.New("export is not ready")
+)
+
+// Service requests exports and signs links to finished ones
+type Service struct {
+	db     *gorm.DB
+	store  storage.Store
+	email  *email.Service
+	config *config.Config
+}
+
+// NewService creates a new export service
+func NewService(db *gorm.DB, store storage.Store, cfg *config.Config) *Service {
+	return &Service{
+		db:     db,
+		store:  store,
+		email:  email.NewService(db, cfg),
+		config: cfg,
+	}
+}
+
+// Request queues an export of the user's data. A user has at most one
+// export waiting or being built; asking again returns it with
+// ErrInProgress.
+func (s *Service) Request(ctx context.Context, userID uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialises concurrent requests of the same user
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("user_id = ? AND status IN ?", userID, []string{models.ExportStatusPending, models.ExportStatusProcessing}).
+			Limit(1).
+			Find(&export)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return ErrInProgress
+		}
+
+		export = models.DataExport{UserID: userID, Status: models.ExportStatusPending}
+		return tx.Create(&export).Error
+	})
+	if err != nil && !errors.Is(err, ErrInProgress) {
+		return nil, err
+	}
+	return &export, err
+}
+
+// List returns the user's exports, newest first
+func (s *Service) List(ctx context.Context, userID uuid.UUID) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&exports).Error
+	return exports, err
+}
+
+// Get returns one of the user's exports
+func (s *Service) Get(ctx context.Context, userID, id uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// URL signs a download link to a ready export. The link lasts
+// STORAGE_URL_EXPIRY at most, and never past the export's expiry.
+func (s *Service) URL(ctx context.Context, export *models.DataExport) (string, error) {
+	if export.Status != models.ExportStatusReady || export.ExpiresAt == nil {
+		return "", ErrNotReady
+	}
+	expiry := min(s.config.Storage.URLExpiry, time.Until(*export.ExpiresAt))
+	if expiry <= 0 {
+		return "", ErrNotReady
+	}
+	return s.store.SignedURL(ctx, export.StorageKey, expiry)
+}
@@ -0,0 +1,193 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nppe-pro/api/internal/jobs"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// exportBatch is the number of exports built per run
+	exportBatch = 5
+	// staleAfter is how long an export may stay processing before another
+	// run assumes its worker died and builds it again
+	staleAfter = 30 * time.Minute
+)
+
+// Worker builds requested exports, notifies their owners and removes
+// expired archives
+type Worker struct {
+	service *Service
+}
+
+// NewWorker creates a worker backed by the given export service
+func NewWorker(service *Service) *Worker {
+	return &Worker{service: service}
+}
+
+// Job returns the worker as a periodic background job
+func (w *Worker) Job() jobs.Job {
+	return jobs.Job{
+		Name:     "data_export",
+		Interval: w.service.config.Account.ExportInterval,
+		Run: func(ctx context.Context) error {
+			return w.Run(ctx, time.Now())
+		},
+	}
+}
+
+// Run expires old archives, then builds the exports waiting as of now
+func (w *Worker) Run(ctx context.Context, now time.Time) error {
+	if err := w.expire(ctx, now); err != nil {
+		return err
+	}
+
+	built, failed := 0, 0
+	for i := 0; i < exportBatch; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		export, err := w.claim(ctx, now)
+		if err != nil {
+			return err
+		}
+		if export == nil {
+			break
+		}
+
+		if err := w.build(ctx, export); err != nil {
+			log.Printf("⚠️ Data export %s failed: %v", export.ID, err)
+			if err := w.service.db.WithContext(ctx).Model(export).Updates(map[string]interface{}{
+				"status":     models.ExportStatusFailed,
+				"last_error": err.Error(),
+			}).Error; err != nil {
+				return fmt.Errorf("failed to record export failure: %w", err)
+			}
+			failed++
+			continue
+		}
+		built++
+	}
+
+	if built > 0 || failed > 0 {
+		log.Printf("📦 Data exports: built %d, failed %d", built, failed)
+	}
+	return nil
+}
+
+// claim marks the oldest waiting export as processing. Exports stuck in
+// processing past staleAfter are claimed again.
+func (w *Worker) claim(ctx context.Context, now time.Time) (*models.DataExport, error) {
+	var export models.DataExport
+	found := false
+	err := w.service.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND updated_at < ?)",
+				models.ExportStatusPending, models.ExportStatusProcessing, now.Add(-staleAfter)).
+			Order("created_at ASC").
+			Limit(1).
+			Find(&export)
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim export: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		found = true
+		return tx.Model(&export).Update("status", models.ExportStatusProcessing).Error
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// build writes the archive to a temporary file, uploads it, and marks the
+// export ready with an in-app notification and an email
+func (w *Worker) build(ctx context.Context, export *models.DataExport) error {
+	s := w.service
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", export.UserID).Error; err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := writeArchive(ctx, s.db, &user, f); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := "exports/" + user.ID.String() + "/" + export.ID.String() + ".zip"
+	if err := s.store.Put(ctx, key, f, size, "application/zip"); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.Account.ExportTTL)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(export).Updates(map[string]interface{}{
+			"status":       models.ExportStatusReady,
+			"storage_key":  key,
+			"size":         size,
+			"last_error":   "",
+			"completed_at": now,
+			"expires_at":   expiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.Notification{
+			UserID:  user.ID,
+			Type:    "system",
+			Title:   "Your data export is ready",
+			Message: "Download the copy of your data before " + expiresAt.UTC().Format("January 2, 2006") + ".",
+			Link:    "/settings/privacy",
+		}).Error; err != nil {
+			return err
+		}
+		return s.email.QueueDataExport(tx, &user, expiresAt)
+	})
+}
+
+// expire deletes the archives of exports past their expiry
+func (w *Worker) expire(ctx context.Context, now time.Time) error {
+	db := w.service.db.WithContext(ctx)
+	var expired []models.DataExport
+	if err := db.Where("status = ? AND expires_at <= ?", models.ExportStatusReady, now).Find(&expired).Error; err != nil {
+		return fmt.Errorf("failed to find expired exports: %w", err)
+	}
+
+	for i := range expired {
+		if err := w.service.store.Delete(ctx, expired[i].StorageKey); err != nil {
+			log.Printf("⚠️ Failed to delete expired export %s: %v", expired[i].ID, err)
+			continue
+		}
+		if err := db.Model(&expired[i]).Updates(map[string]interface{}{
+			"status":      models.ExportStatusExpired,
+			"storage_key": "",
+		}).Error; err != nil {
+			return fmt.Errorf("failed to expire export %s: %w", expired[i].ID, err)
+		}
+	}
+	return nil
+}
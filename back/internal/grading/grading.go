@@ -0,0 +1,108 @@
+// Package grading scores a candidate's selection against a question's
+// answer key. Single-answer questions are right or wrong; multi-select
+// questions can optionally earn partial credit.
+package grading
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+)
+
+// Scoring modes for multi-select questions
+const (
+	// AllOrNothing gives credit only when exactly the correct options are selected
+	AllOrNothing = "all_or_nothing"
+	// Partial gives one share per correct option selected, minus one share
+	// per incorrect option selected, floored at zero
+	Partial = "partial"
+)
+
+var (
+	ErrNoSelection         = errors.New("at least one option must be selected")
+	ErrTooManySelected     = errors.New("this question accepts a single option")
+	ErrOptionNotOnQuestion = errors.New("option does not belong to this question")
+)
+
+// ValidMode reports whether mode is a known scoring mode
+func ValidMode(mode string) bool {
+	return mode == AllOrNothing || mode == Partial
+}
+
+// IsMultiSelect reports whether a question type accepts several options
+func IsMultiSelect(questionType string) bool {
+	return questionType == "multiple_choice_multi"
+}
+
+// Outcome is the result of grading one answer
+type Outcome struct {
+	Correct bool    // exactly the correct options were selected
+	Credit  float64 // 0..1, what the answer contributes to the score
+}
+
+// Validate checks that selected is an acceptable answer to q
+func Validate(q *models.Question, selected models.UUIDSet) error {
+	if len(selected) == 0 {
+		return ErrNoSelection
+	}
+	if len(selected) > 1 && !IsMultiSelect(q.QuestionType) {
+		return ErrTooManySelected
+	}
+
+	valid := make(map[uuid.UUID]bool, len(q.Options))
+	for _, opt := range q.Options {
+		valid[opt.ID] = true
+	}
+	for _, id := range selected {
+		if !valid[id] {
+			return ErrOptionNotOnQuestion
+		}
+	}
+	return nil
+}
+
+// Grade scores selected against the options of a question. Questions that
+// are not multi-select are always graded all-or-nothing.
+func Grade(q *models.Question, selected models.UUIDSet, mode string) Outcome {
+	correctTotal, correctHits, wrongHits := 0, 0, 0
+	for _, opt := range q.Options {
+		picked := selected.Contains(opt.ID)
+		switch {
+		case opt.IsCorrect:
+			correctTotal++
+			if picked {
+				correctHits++
+			}
+		case picked:
+			wrongHits++
+		}
+	}
+
+	exact := len(selected) > 0 && correctHits == correctTotal && wrongHits == 0
+	outcome := Outcome{Correct: exact}
+	if exact {
+		outcome.Credit = 1
+	}
+
+	if mode == Partial && IsMultiSelect(q.QuestionType) && correctTotal > 0 {
+		credit := float64(correctHits-wrongHits) / float64(correctTotal)
+		if credit < 0 {
+			credit = 0
+		}
+		outcome.Credit = credit
+	}
+
+	return outcome
+}
+
+// CorrectOptionIDs returns the IDs of every correct option of q
+func CorrectOptionIDs(q *models.Question) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, 1)
+	for _, opt := range q.Options {
+		if opt.IsCorrect {
+			ids = append(ids, opt.ID)
+		}
+	}
+	return ids
+}
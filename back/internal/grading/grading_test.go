@@ -0,0 +1,97 @@
+package grading
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+)
+
+// question builds a question whose options are correct as given
+func question(questionType string, correct ...bool) (*models.Question, []uuid.UUID) {
+	q := &models.Question{ID: uuid.New(), QuestionType: questionType}
+	ids := make([]uuid.UUID, len(correct))
+	for i, c := range correct {
+		ids[i] = uuid.New()
+		q.Options = append(q.Options, models.QuestionOption{ID: ids[i], QuestionID: q.ID, IsCorrect: c, Position: i + 1})
+	}
+	return q, ids
+}
+
+func TestGrade(t *testing.T) {
+	single, s := question("multiple_choice_single", false, true, false, false)
+	multi, m := question("multiple_choice_multi", true, true, true, false)
+
+	tests := []struct {
+		name        string
+		q           *models.Question
+		selected    models.UUIDSet
+		mode        string
+		wantCorrect bool
+		wantCredit  float64
+	}{
+		{"single right", single, models.NewUUIDSet(s[1]), AllOrNothing, true, 1},
+		{"single wrong", single, models.NewUUIDSet(s[0]), AllOrNothing, false, 0},
+		{"single ignores partial mode", single, models.NewUUIDSet(s[0]), Partial, false, 0},
+		{"nothing selected", single, nil, AllOrNothing, false, 0},
+		{"multi exact", multi, models.NewUUIDSet(m[0], m[1], m[2]), AllOrNothing, true, 1},
+		{"multi missing one", multi, models.NewUUIDSet(m[0], m[1]), AllOrNothing, false, 0},
+		{"multi extra wrong", multi, models.NewUUIDSet(m[0], m[1], m[2], m[3]), AllOrNothing, false, 0},
+		{"partial exact", multi, models.NewUUIDSet(m[0], m[1], m[2]), Partial, true, 1},
+		{"partial two of three", multi, models.NewUUIDSet(m[0], m[1]), Partial, false, 2.0 / 3},
+		{"partial wrong cancels right", multi, models.NewUUIDSet(m[0], m[1], m[3]), Partial, false, 1.0 / 3},
+		{"partial floored at zero", multi, models.NewUUIDSet(m[3]), Partial, false, 0},
+		{"partial nothing selected", multi, nil, Partial, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Grade(tt.q, tt.selected, tt.mode)
+			if got.Correct != tt.wantCorrect || got.Credit != tt.wantCredit {
+				t.Errorf("Grade = %+v, want {Correct:%v Credit:%v}", got, tt.wantCorrect, tt.wantCredit)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	single, s := question("multiple_choice_single", true, false)
+	multi, m := question("multiple_choice_multi", true, true, false)
+
+	tests := []struct {
+		name     string
+		q        *models.Question
+		selected models.UUIDSet
+		want     error
+	}{
+		{"single option", single, models.NewUUIDSet(s[0]), nil},
+		{"several options on single", single, models.NewUUIDSet(s[0], s[1]), ErrTooManySelected},
+		{"several options on multi", multi, models.NewUUIDSet(m[0], m[2]), nil},
+		{"empty", multi, nil, ErrNoSelection},
+		{"option of another question", single, models.NewUUIDSet(m[0]), ErrOptionNotOnQuestion},
+		{"one foreign option among valid ones", multi, models.NewUUIDSet(m[0], s[0]), ErrOptionNotOnQuestion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.q, tt.selected); !errors.Is(err, tt.want) {
+				t.Errorf("Validate = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrectOptionIDs(t *testing.T) {
+	q, ids := question("multiple_choice_multi", true, false, true)
+	got := CorrectOptionIDs(q)
+	if len(got) != 2 || got[0] != ids[0] || got[1] != ids[2] {
+		t.Errorf("CorrectOptionIDs = %v, want [%s %s]", got, ids[0], ids[2])
+	}
+}
+
+func TestValidMode(t *testing.T) {
+	for mode, want := range map[string]bool{AllOrNothing: true, Partial: true, "": false, "strict": false} {
+		if got := ValidMode(mode); got != want {
+			t.Errorf("ValidMode(%q) = %v, want %v", mode, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/achievements"
+	"github.com/nppe-pro/api/internal/handlers/dto"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/database"
+	"gorm.io/gorm"
+)
+
+type AchievementHandler struct {
+	db           *gorm.DB
+	redis        *database.RedisClient
+	achievements *achievements.Service
+}
+
+func NewAchievementHandler(db *gorm.DB, redis *database.RedisClient) *AchievementHandler {
+	return &AchievementHandler{
+		db:           db,
+		redis:        redis,
+		achievements: achievements.NewService(db),
+	}
+}
+
+// GetAchievements returns the achievements and badges the user has earned
+func (h *AchievementHandler) GetAchievements(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	awards, err := h.achievements.Earned(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	resp := dto.AchievementsResponse{
+		Achievements: make([]dto.EarnedAchievementResponse, 0),
+		Badges:       make([]dto.EarnedAchievementResponse, 0),
+	}
+	for _, a := range awards {
+		earned := dto.EarnedAchievementResponse{
+			AchievementResponse: buildAchievementResponse(a.Definition),
+			EarnedAt:            a.EarnedAt,
+			SourceType:          a.SourceType,
+			SourceID:            a.SourceID,
+		}
+		if a.Kind == models.AwardKindBadge {
+			resp.Badges = append(resp.Badges, earned)
+		} else {
+			resp.Achievements = append(resp.Achievements, earned)
+		}
+		resp.TotalPoints += a.Points
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetAchievementProgress returns every active achievement and badge with
+// the user's progress towards it
+func (h *AchievementHandler) GetAchievementProgress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	progress, err := h.achievements.Progress(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievement progress"})
+		return
+	}
+
+	resp := make([]dto.AchievementProgressResponse, len(progress))
+	for i, p := range progress {
+		resp[i] = dto.AchievementProgressResponse{
+			AchievementResponse: buildAchievementResponse(p.Definition),
+			Requirement:         p.Requirement,
+			Current:             p.Current,
+			Target:              p.Threshold,
+			Progress:            math.Round(p.Percentage*10) / 10,
+			Unlocked:            p.Unlocked,
+			UnlockedAt:          p.UnlockedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Helper function to build the achievement definition DTO
+func buildAchievementResponse(d achievements.Definition) dto.AchievementResponse {
+	return dto.AchievementResponse{
+		ID:          d.ID,
+		Code:        d.Code,
+		Kind:        d.Kind,
+		Name:        d.Name,
+		Description: d.Description,
+		Category:    d.Category,
+		Icon:        d.Icon,
+		Rarity:      d.Rarity,
+		Points:      d.Points,
+		Family:      d.Family,
+		Level:       d.Level,
+	}
+}
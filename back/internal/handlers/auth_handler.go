@@ -1,418 +1,949 @@
-package handlers
-
-import (
-	"context"
-	"net/http"
-	"strings"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"github.com/nppe-pro/api/config"
-	"github.com/nppe-pro/api/internal/models"
-	"github.com/nppe-pro/api/pkg/database"
-	"github.com/nppe-pro/api/pkg/jwt"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
-)
-
-type AuthHandler struct {
-	db         *gorm.DB
-	redis      *database.RedisClient
-	jwtService *jwt.JWTService
-	config     *config.Config
-}
-
-func NewAuthHandler(db *gorm.DB, redis *database.RedisClient, jwtService *jwt.JWTService, cfg *config.Config) *AuthHandler {
-	return &AuthHandler{
-		db:         db,
-		redis:      redis,
-		jwtService: jwtService,
-		config:     cfg,
-	}
-}
-
-// getSameSiteMode converts string SameSite value to Gin constant
-func (h *AuthHandler) getSameSiteMode() http.SameSite {
-	switch strings.ToLower(h.config.Server.CookieSameSite) {
-	case "strict":
-		return http.SameSiteStrictMode
-	case "none":
-		return http.SameSiteNoneMode
-	case "lax":
-		return http.SameSiteLaxMode
-	default:
-		return http.SameSiteLaxMode
-	}
-}
-
-type RegisterRequest struct {
-	Email     string     `json:"email" binding:"required,email"`
-	Password  string     `json:"password" binding:"required,min=8"`
-	FirstName string     `json:"first_name" binding:"required"`
-	LastName  string     `json:"last_name" binding:"required"`
-	Province  string     `json:"province" binding:"required"`
-	ExamDate  *time.Time `json:"exam_date"`
-}
-
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-}
-
-type LoginResponse struct {
-	AccessToken  string      `json:"access_token"`
-	RefreshToken string      `json:"refresh_token"`
-	ExpiresIn    int         `json:"expires_in"`
-	User         interface{} `json:"user"`
-}
-
-// Register handles user registration
-// @Summary Register a new user
-// @Description Register a new user account
-// @Tags auth
-// @Accept json
-// @Produce json
-// @Param request body RegisterRequest true "Registration details"
-// @Success 201 {object} LoginResponse
-// @Failure 400 {object} map[string]string
-// @Router /auth/register [post]
-func (h *AuthHandler) Register(c *gin.Context) {
-	var req RegisterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Check if user already exists
-	var existingUser models.User
-	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already registered"})
-		return
-	}
-
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
-		return
-	}
-
-	// Create user
-	user := models.User{
-		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Province:     req.Province,
-		ExamDate:     req.ExamDate,
-		IsVerified:   false,
-	}
-
-	if err := h.db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-		return
-	}
-
-	// Create user stats
-	stats := models.UserStats{
-		UserID: user.ID,
-	}
-	h.db.Create(&stats)
-
-	// Generate tokens
-	accessToken, _ := h.jwtService.GenerateToken(user.ID, user.Email, user.IsAdmin)
-	refreshToken, _ := h.jwtService.GenerateRefreshToken(user.ID)
-
-	// Store session in Redis
-	ctx := context.Background()
-	h.redis.Set(ctx, "session:"+user.ID.String(), accessToken, 24*time.Hour)
-
-	// Set HTTP-only cookies
-	c.SetSameSite(h.getSameSiteMode())
-	c.SetCookie(
-		"access_token",
-		accessToken,
-		3600, // 1 hour
-		"/",
-		"",
-		h.config.Server.CookieSecure,
-		true, // HTTP-only
-	)
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		86400*7, // 7 days
-		"/",
-		"",
-		h.config.Server.CookieSecure,
-		true,
-	)
-
-	c.JSON(http.StatusCreated, LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    3600,
-		User: gin.H{
-			"id":           user.ID,
-			"email":        user.Email,
-			"first_name":   user.FirstName,
-			"last_name":    user.LastName,
-			"province":     user.Province,
-			"is_verified":  user.IsVerified,
-			"is_admin":     user.IsAdmin,
-			"avatar_url":   user.AvatarURL,
-			"study_streak": user.StudyStreak,
-		},
-	})
-}
-
-// Login handles user login
-// @Summary Login user
-// @Description Authenticate user and return JWT token
-// @Tags auth
-// @Accept json
-// @Produce json
-// @Param request body LoginRequest true "Login credentials"
-// @Success 200 {object} LoginResponse
-// @Failure 401 {object} map[string]string
-// @Router /auth/login [post]
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Find user
-	var user models.User
-	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	// Generate tokens
-	accessToken, _ := h.jwtService.GenerateToken(user.ID, user.Email, user.IsAdmin)
-	refreshToken, _ := h.jwtService.GenerateRefreshToken(user.ID)
-
-	// Store session in Redis
-	ctx := context.Background()
-	h.redis.Set(ctx, "session:"+user.ID.String(), accessToken, 24*time.Hour)
-
-	// Set HTTP-only cookies
-	c.SetSameSite(h.getSameSiteMode())
-	c.SetCookie(
-		"access_token",
-		accessToken,
-		3600, // 1 hour
-		"/",
-		"",
-		h.config.Server.CookieSecure,
-		true, // HTTP-only
-	)
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		86400*7, // 7 days
-		"/",
-		"",
-		h.config.Server.CookieSecure,
-		true,
-	)
-
-	c.JSON(http.StatusOK, LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    3600,
-		User: gin.H{
-			"id":           user.ID,
-			"email":        user.Email,
-			"first_name":   user.FirstName,
-			"last_name":    user.LastName,
-			"province":     user.Province,
-			"is_verified":  user.IsVerified,
-			"is_admin":     user.IsAdmin,
-			"avatar_url":   user.AvatarURL,
-			"study_streak": user.StudyStreak,
-		},
-	})
-}
-
-// RefreshToken handles token refresh
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	var req struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	userID, err := h.jwtService.ValidateRefreshToken(req.RefreshToken)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
-		return
-	}
-
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
-		return
-	}
-
-	accessToken, _ := h.jwtService.GenerateToken(user.ID, user.Email, user.IsAdmin)
-	newRefreshToken, _ := h.jwtService.GenerateRefreshToken(user.ID)
-
-	c.JSON(http.StatusOK, LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-		ExpiresIn:    3600,
-	})
-}
-
-// ForgotPassword handles password reset request
-func (h *AuthHandler) ForgotPassword(c *gin.Context) {
-	var req struct {
-		Email string `json:"email" binding:"required,email"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Find user
-	var user models.User
-	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		// Don't reveal if email exists
-		c.JSON(http.StatusOK, gin.H{"message": "If the email exists, a reset link will be sent"})
-		return
-	}
-
-	// Create reset token
-	resetToken := uuid.New().String()
-	passwordReset := models.PasswordReset{
-		UserID:    user.ID,
-		Token:     resetToken,
-		ExpiresAt: time.Now().Add(1 * time.Hour),
-	}
-	h.db.Create(&passwordReset)
-
-	// TODO: Send email with reset link
-	// For now, just return success
-	c.JSON(http.StatusOK, gin.H{"message": "If the email exists, a reset link will be sent"})
-}
-
-// ResetPassword handles password reset with token
-func (h *AuthHandler) ResetPassword(c *gin.Context) {
-	var req struct {
-		Token    string `json:"token" binding:"required"`
-		Password string `json:"password" binding:"required,min=8"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Find reset token
-	var reset models.PasswordReset
-	if err := h.db.Where("token = ? AND used_at IS NULL AND expires_at > ?", req.Token, time.Now()).First(&reset).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
-		return
-	}
-
-	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
-		return
-	}
-
-	// Update user password
-	now := time.Now()
-	h.db.Model(&models.User{}).Where("id = ?", reset.UserID).Update("password_hash", string(hashedPassword))
-	h.db.Model(&reset).Update("used_at", &now)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Password reset successful"})
-}
-
-// VerifyEmail handles email verification
-func (h *AuthHandler) VerifyEmail(c *gin.Context) {
-	token := c.Param("token")
-
-	var verification models.EmailVerification
-	if err := h.db.Where("token = ? AND expires_at > ?", token, time.Now()).First(&verification).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
-		return
-	}
-
-	// Update user
-	h.db.Model(&models.User{}).Where("id = ?", verification.UserID).Update("is_verified", true)
-	h.db.Delete(&verification)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
-}
-
-// GoogleLogin initiates Google OAuth
-func (h *AuthHandler) GoogleLogin(c *gin.Context) {
-	// TODO: Implement Google OAuth
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Google OAuth not implemented yet"})
-}
-
-// GoogleCallback handles Google OAuth callback
-func (h *AuthHandler) GoogleCallback(c *gin.Context) {
-	// TODO: Implement Google OAuth callback
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "Google OAuth callback not implemented yet"})
-}
-
-// Logout handles user logout
-func (h *AuthHandler) Logout(c *gin.Context) {
-	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("user_id")
-	if exists {
-		// Clear session from Redis
-		ctx := context.Background()
-		sessionKey := "session:" + userID.(uuid.UUID).String()
-		h.redis.Client.Del(ctx, sessionKey)
-	}
-
-	// Clear cookies
-	c.SetCookie("access_token", "", -1, "/", "", false, true)
-	c.SetCookie("refresh_token", "", -1, "/", "", false, true)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
-}
-
-// GetCurrentUser returns current authenticated user info
-func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
-		return
-	}
-
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":           user.ID,
-		"email":        user.Email,
-		"first_name":   user.FirstName,
-		"last_name":    user.LastName,
-		"province":     user.Province,
-		"exam_date":    user.ExamDate,
-		"is_verified":  user.IsVerified,
-		"is_admin":     user.IsAdmin,
-		"avatar_url":   user.AvatarURL,
-		"study_streak": user.StudyStreak,
-		"created_at":   user.CreatedAt,
-	})
-}
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/email"
+	"github.com/nppe-pro/api/internal/lockout"
+	"github.com/nppe-pro/api/internal/media"
+	"github.com/nppe-pro/api/internal/mfa"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/internal/oauth"
+	"github.com/nppe-pro/api/internal/province"
+	"github.com/nppe-pro/api/internal/rbac"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/jwt"
+	"github.com/nppe-pro/api/pkg/middleware"
+	"github.com/nppe-pro/api/pkg/storage"
+	"github.com/nppe-pro/api/pkg/tokens"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type AuthHandler struct {
+	db         *gorm.DB
+	redis      *database.RedisClient
+	jwtService *jwt.JWTService
+	tokens     *tokens.Store
+	oauth      *oauth.Service
+	email      *email.Service
+	mfa        *mfa.Service
+	lockout    *lockout.Guard
+	rbac       *rbac.Service
+	media      *media.Service
+	config     *config.Config
+}
+
+func NewAuthHandler(db *gorm.DB, redis *database.RedisClient, jwtService *jwt.JWTService, tokenStore *tokens.Store, store storage.Store, cfg *config.Config) *AuthHandler {
+	return &AuthHandler{
+		db:         db,
+		redis:      redis,
+		jwtService: jwtService,
+		tokens:     tokenStore,
+		oauth:      oauth.NewService(db, redis, cfg),
+		email:      email.NewService(db, cfg),
+		mfa:        mfa.NewService(db, redis, cfg),
+		lockout:    lockout.NewGuard(db, redis, cfg),
+		rbac:       rbac.NewService(db),
+		media:      media.NewService(store, cfg),
+		config:     cfg,
+	}
+}
+
+// getSameSiteMode converts string SameSite value to Gin constant
+func (h *AuthHandler) getSameSiteMode() http.SameSite {
+	switch strings.ToLower(h.config.Server.CookieSameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	case "lax":
+		return http.SameSiteLaxMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+type RegisterRequest struct {
+	Email     string     `json:"email" binding:"required,email"`
+	Password  string     `json:"password" binding:"required,min=8"`
+	FirstName string     `json:"first_name" binding:"required"`
+	LastName  string     `json:"last_name" binding:"required"`
+	Province  string     `json:"province" binding:"required"`
+	ExamDate  *time.Time `json:"exam_date"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"` // falls back to the refresh_token cookie
+}
+
+type LoginResponse struct {
+	AccessToken   string      `json:"access_token"`
+	RefreshToken  string      `json:"refresh_token"`
+	ExpiresIn     int         `json:"expires_in"`
+	User          interface{} `json:"user"`
+	RecoveryCodes []string    `json:"recovery_codes,omitempty"` // shown once, after enrolling at login
+}
+
+// MFAChallengeResponse is returned by Login instead of tokens when the
+// account needs a second factor
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"mfa_enrollment_required"` // enrol via /auth/mfa/enroll first
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int    `json:"expires_in"`
+}
+
+type MFAChallengeRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code"`
+}
+
+// Register handles user registration
+// @Summary Register a new user
+// @Description Register a new user account
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RegisterRequest true "Registration details"
+// @Success 201 {object} LoginResponse
+// @Failure 400 {object} map[string]string
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	regulator, ok := province.Lookup(req.Province)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown province"})
+		return
+	}
+
+	// Check if user already exists
+	var existingUser models.User
+	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already registered"})
+		return
+	}
+
+	// Hash password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+		return
+	}
+
+	// Create user
+	user := models.User{
+		Email:        req.Email,
+		PasswordHash: string(hashedPassword),
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Province:     regulator.Code,
+		ExamDate:     req.ExamDate,
+		IsVerified:   false,
+	}
+
+	// Create the user, their stats and the verification email together so a
+	// failure never leaves an account nobody can verify
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.UserStats{UserID: user.ID}).Error; err != nil {
+			return err
+		}
+
+		verification := models.EmailVerification{
+			UserID:    user.ID,
+			Token:     uuid.New().String(),
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+		}
+		if err := tx.Create(&verification).Error; err != nil {
+			return err
+		}
+
+		return h.email.QueueVerification(tx, &user, &verification)
+	})
+	if err != nil {
+		log.Printf("Failed to register %s: %v", req.Email, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	// Start a session (refresh token family) and set the auth cookies
+	resp, err := h.startSession(c, &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Login handles user login
+// @Summary Login user
+// @Description Authenticate user and return JWT token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "Login credentials"
+// @Success 200 {object} LoginResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attempt := lockout.Attempt{Account: req.Email, IP: c.ClientIP()}
+	if throttled(c, h.lockout, lockout.ScopeLogin, attempt) {
+		return
+	}
+
+	// Find user
+	var user models.User
+	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		recordFailure(c, h.lockout, lockout.ScopeLogin, attempt)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	// Verify password
+	attempt.UserID = &user.ID
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		recordFailure(c, h.lockout, lockout.ScopeLogin, attempt)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	if err := h.lockout.Succeed(c.Request.Context(), lockout.ScopeLogin, req.Email); err != nil {
+		log.Printf("⚠️ Failed to clear login failures for %s: %v", user.ID, err)
+	}
+
+	// Accounts with a second factor continue at /auth/mfa/verify
+	needsMFA, err := h.mfa.Needed(c.Request.Context(), &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA challenge"})
+		return
+	}
+	if needsMFA {
+		challenge, err := h.mfa.NewChallenge(c.Request.Context(), &user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA challenge"})
+			return
+		}
+		c.JSON(http.StatusOK, MFAChallengeResponse{
+			MFARequired:        true,
+			EnrollmentRequired: challenge.Enroll,
+			MFAToken:           challenge.Token,
+			ExpiresIn:          int(h.config.MFA.ChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	// Start a session (refresh token family) and set the auth cookies
+	resp, err := h.startSession(c, &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// EnrollMFA starts enrolment for a login challenge whose account must use
+// MFA but has not set it up. The code from the new authenticator then
+// completes the login at VerifyMFA.
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	var req MFAChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	challenge, user, ok := h.mfaChallenge(c, req.MFAToken)
+	if !ok {
+		return
+	}
+	if !challenge.Enroll {
+		c.JSON(http.StatusConflict, gin.H{"error": "MFA is already enabled"})
+		return
+	}
+
+	secret, uri, err := h.mfa.Enroll(ctx, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// VerifyMFA completes a login challenge with a TOTP or recovery code. For
+// a challenge that required enrolment the code activates MFA and the
+// response carries the new recovery codes.
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req MFAChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	challenge, user, ok := h.mfaChallenge(c, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	// Wrong codes count towards the account's MFA lockout. A correct
+	// password clears the login counter, so that one would reset with
+	// every new challenge.
+	attempt := lockout.Attempt{Account: user.Email, IP: c.ClientIP(), UserID: &user.ID}
+	if throttled(c, h.lockout, lockout.ScopeMFA, attempt) {
+		return
+	}
+
+	var recoveryCodes []string
+	var err error
+	if challenge.Enroll {
+		recoveryCodes, err = h.mfa.Activate(ctx, user, req.Code)
+	} else {
+		err = h.mfa.Verify(ctx, user, req.Code)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, mfa.ErrInvalidCode):
+			if err := h.mfa.FailChallenge(ctx, challenge); err != nil {
+				log.Printf("⚠️ Failed to count MFA attempt for %s: %v", user.ID, err)
+			}
+			recordFailure(c, h.lockout, lockout.ScopeMFA, attempt)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		case errors.Is(err, mfa.ErrNotEnrolled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "MFA enrollment has not been started"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		}
+		return
+	}
+
+	if err := h.mfa.EndChallenge(ctx, challenge); err != nil {
+		log.Printf("⚠️ Failed to end MFA challenge for %s: %v", user.ID, err)
+	}
+	if err := h.lockout.Succeed(ctx, lockout.ScopeMFA, user.Email); err != nil {
+		log.Printf("⚠️ Failed to clear MFA failures for %s: %v", user.ID, err)
+	}
+
+	resp, err := h.startSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	resp.RecoveryCodes = recoveryCodes
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// mfaChallenge loads a login challenge and its user, responding with an
+// error if either is gone
+func (h *AuthHandler) mfaChallenge(c *gin.Context, token string) (*mfa.Challenge, *models.User, bool) {
+	challenge, err := h.mfa.Challenge(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, mfa.ErrChallengeExpired) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA challenge expired, please log in again"})
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify MFA challenge"})
+		}
+		return nil, nil, false
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", challenge.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA challenge expired, please log in again"})
+		return nil, nil, false
+	}
+	return challenge, &user, true
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh
+// token. Each refresh token can be used once; presenting a used one again
+// revokes its whole family, logging out both the user and whoever stole it.
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = c.Cookie("refresh_token")
+	}
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+		return
+	}
+
+	claims, err := h.jwtService.ValidateRefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	userID, _ := claims.UserID()
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	refreshToken, jti, err := h.jwtService.GenerateRefreshToken(user.ID, claims.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	if err := h.tokens.Rotate(c.Request.Context(), claims, jti, clientOf(c)); err != nil {
+		switch {
+		case errors.Is(err, tokens.ErrReused):
+			h.clearAuthCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, please log in again"})
+		case errors.Is(err, tokens.ErrRevoked):
+			h.clearAuthCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has been revoked"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	// Role changes take effect here, at the latest when the access token expires
+	grants, err := h.rbac.Grants(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	accessToken, err := h.jwtService.GenerateToken(user.ID, user.Email, grants.Roles, grants.Permissions, claims.FamilyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	h.setAuthCookies(c, accessToken, refreshToken)
+	c.JSON(http.StatusOK, LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.config.JWT.Expiration.Seconds()),
+	})
+}
+
+// ForgotPassword handles password reset request
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every request counts, whether or not the email exists, so the limit
+	// reveals nothing and bounds how often a mailbox can be spammed
+	attempt := lockout.Attempt{Account: req.Email, IP: c.ClientIP()}
+	if throttled(c, h.lockout, lockout.ScopeForgotPassword, attempt) {
+		return
+	}
+	recordFailure(c, h.lockout, lockout.ScopeForgotPassword, attempt)
+
+	// Find user
+	var user models.User
+	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		// Don't reveal if email exists
+		c.JSON(http.StatusOK, gin.H{"message": "If the email exists, a reset link will be sent"})
+		return
+	}
+
+	// Create reset token
+	resetToken := uuid.New().String()
+	passwordReset := models.PasswordReset{
+		UserID:    user.ID,
+		Token:     resetToken,
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&passwordReset).Error; err != nil {
+			return err
+		}
+		return h.email.QueuePasswordReset(tx, &user, &passwordReset)
+	})
+	if err != nil {
+		log.Printf("Failed to queue password reset for %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the email exists, a reset link will be sent"})
+}
+
+// ResetPassword handles password reset with token
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=8"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Token guesses are counted per IP
+	attempt := lockout.Attempt{IP: c.ClientIP()}
+	if throttled(c, h.lockout, lockout.ScopeResetPassword, attempt) {
+		return
+	}
+
+	// Find reset token
+	var reset models.PasswordReset
+	if err := h.db.Where("token = ? AND used_at IS NULL AND expires_at > ?", req.Token, time.Now()).First(&reset).Error; err != nil {
+		recordFailure(c, h.lockout, lockout.ScopeResetPassword, attempt)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		return
+	}
+
+	// Hash new password
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+		return
+	}
+
+	// Update user password
+	now := time.Now()
+	h.db.Model(&models.User{}).Where("id = ?", reset.UserID).Update("password_hash", string(hashedPassword))
+	h.db.Model(&reset).Update("used_at", &now)
+
+	// A reset usually means the old password leaked; end every session
+	if err := h.tokens.RevokeAll(c.Request.Context(), reset.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke existing sessions"})
+		return
+	}
+
+	// Whoever locked the account no longer knows the password
+	var user models.User
+	if err := h.db.First(&user, "id = ?", reset.UserID).Error; err == nil {
+		if err := h.lockout.Unlock(c.Request.Context(), &user, &user.ID, "password_reset"); err != nil {
+			log.Printf("⚠️ Failed to lift lockout for %s: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successful"})
+}
+
+// UnlockUser lets an admin lift a login lockout
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := h.lockout.Unlock(c.Request.Context(), &user, &adminID, "admin"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+// VerifyEmail handles email verification
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Param("token")
+
+	var verification models.EmailVerification
+	if err := h.db.Where("token = ? AND expires_at > ?", token, time.Now()).First(&verification).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+		return
+	}
+
+	// A verification for a new address also switches the account to it
+	updates := map[string]interface{}{"is_verified": true}
+	if verification.Email != "" {
+		updates["email"] = verification.Email
+		updates["pending_email"] = ""
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if verification.Email != "" {
+			var taken int64
+			if err := tx.Model(&models.User{}).
+				Where("email = ? AND id <> ?", verification.Email, verification.UserID).
+				Count(&taken).Error; err != nil {
+				return err
+			}
+			if taken > 0 {
+				return errEmailTaken
+			}
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", verification.UserID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&verification).Error
+	})
+	if err != nil {
+		if errors.Is(err, errEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// GoogleLogin initiates Google OAuth
+func (h *AuthHandler) GoogleLogin(c *gin.Context) {
+	h.oauthLogin(c, "google")
+}
+
+// GoogleCallback handles Google OAuth callback
+func (h *AuthHandler) GoogleCallback(c *gin.Context) {
+	h.oauthCallback(c, "google")
+}
+
+// oauthLogin redirects the browser to the provider's consent screen
+func (h *AuthHandler) oauthLogin(c *gin.Context, provider string) {
+	url, err := h.oauth.Begin(c.Request.Context(), provider)
+	if err != nil {
+		if errors.Is(err, oauth.ErrUnknownProvider) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": "Login with " + provider + " is not configured"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// oauthCallback completes a provider login, starts a session and sends the
+// browser back to the frontend
+func (h *AuthHandler) oauthCallback(c *gin.Context, provider string) {
+	callback := strings.TrimRight(h.config.Server.FrontendURL, "/") + "/auth/callback"
+
+	if c.Query("error") != "" {
+		c.Redirect(http.StatusFound, callback+"?error=access_denied")
+		return
+	}
+
+	user, created, err := h.oauth.Complete(c.Request.Context(), provider, c.Query("state"), c.Query("code"))
+	if err != nil {
+		reason := "oauth_failed"
+		switch {
+		case errors.Is(err, oauth.ErrUnknownProvider):
+			c.JSON(http.StatusNotImplemented, gin.H{"error": "Login with " + provider + " is not configured"})
+			return
+		case errors.Is(err, oauth.ErrInvalidState):
+			reason = "invalid_state"
+		case errors.Is(err, oauth.ErrEmailNotVerified):
+			reason = "email_not_verified"
+		case errors.Is(err, oauth.ErrAccountConflict):
+			reason = "account_conflict"
+		default:
+			log.Printf("⚠️ %s login failed: %v", provider, err)
+		}
+		c.Redirect(http.StatusFound, callback+"?error="+reason)
+		return
+	}
+
+	// The frontend finishes the login at /auth/mfa/verify
+	needsMFA, err := h.mfa.Needed(c.Request.Context(), user)
+	if err != nil {
+		c.Redirect(http.StatusFound, callback+"?error=session_failed")
+		return
+	}
+	if needsMFA {
+		challenge, err := h.mfa.NewChallenge(c.Request.Context(), user)
+		if err != nil {
+			c.Redirect(http.StatusFound, callback+"?error=session_failed")
+			return
+		}
+		c.Redirect(http.StatusFound, callback+"?mfa_token="+challenge.Token+
+			"&mfa_enrollment_required="+strconv.FormatBool(challenge.Enroll))
+		return
+	}
+
+	if _, err := h.startSession(c, user); err != nil {
+		c.Redirect(http.StatusFound, callback+"?error=session_failed")
+		return
+	}
+
+	c.Redirect(http.StatusFound, callback+"?new_user="+strconv.FormatBool(created))
+}
+
+// Logout ends the current session: the access token is denylisted until
+// it expires and the session's refresh tokens are revoked
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if claims, ok := middleware.GetClaims(c); ok {
+		ctx := c.Request.Context()
+		if err := h.tokens.Deny(ctx, claims); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+		if claims.SessionID != uuid.Nil {
+			if err := h.tokens.RevokeFamily(ctx, claims.UserID, claims.SessionID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+				return
+			}
+		}
+	}
+
+	h.clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll ends every session of the current user on every device
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.tokens.RevokeAll(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	h.clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices"})
+}
+
+// ListSessions returns the devices the current user is signed in on
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessions, err := h.tokens.Sessions(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession signs the current user out of one device
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := h.tokens.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, tokens.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	if claims, ok := middleware.GetClaims(c); ok && claims.SessionID == sessionID {
+		h.clearAuthCookies(c)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// GetCurrentUser returns current authenticated user info
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", claims.UserID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	grants := rbac.Grants{Roles: claims.Roles, Permissions: claims.Permissions}
+	c.JSON(http.StatusOK, gin.H{
+		"id":           user.ID,
+		"email":        user.Email,
+		"first_name":   user.FirstName,
+		"last_name":    user.LastName,
+		"province":     user.Province,
+		"exam_date":    user.ExamDate,
+		"is_verified":  user.IsVerified,
+		"is_admin":     grants.Staff(),
+		"roles":        grants.Roles,
+		"permissions":  grants.Permissions,
+		"avatar_url":   h.media.AvatarURL(c.Request.Context(), &user),
+		"study_streak": user.StudyStreak,
+		"created_at":   user.CreatedAt,
+	})
+}
+
+// throttled responds with 429 and reports true when attempt has to wait
+// because of earlier failures
+func throttled(c *gin.Context, guard *lockout.Guard, scope lockout.Scope, attempt lockout.Attempt) bool {
+	wait, err := guard.Check(c.Request.Context(), scope, attempt)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify attempt limits"})
+		return true
+	}
+	if wait > 0 {
+		seconds := int((wait + time.Second - 1) / time.Second)
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Too many attempts, please try again later",
+			"retry_after": seconds,
+		})
+		return true
+	}
+	return false
+}
+
+// recordFailure counts a failed attempt. The response to the failure
+// itself does not change; the next attempt is the one that waits.
+func recordFailure(c *gin.Context, guard *lockout.Guard, scope lockout.Scope, attempt lockout.Attempt) {
+	if _, err := guard.Fail(c.Request.Context(), scope, attempt); err != nil {
+		log.Printf("⚠️ Failed to record %s failure: %v", scope, err)
+	}
+}
+
+// loginUser is the user returned with a new session. is_admin tells the
+// frontend whether to show the admin area at all.
+func loginUser(user *models.User, grants rbac.Grants, avatarURL string) gin.H {
+	return gin.H{
+		"id":              user.ID,
+		"email":           user.Email,
+		"first_name":      user.FirstName,
+		"last_name":       user.LastName,
+		"province":        user.Province,
+		"is_verified":     user.IsVerified,
+		"is_admin":        grants.Staff(),
+		"roles":           grants.Roles,
+		"permissions":     grants.Permissions,
+		"mfa_enabled":     mfa.Enabled(user),
+		"avatar_url":      avatarURL,
+		"study_streak":    user.StudyStreak,
+		"deletion_due_at": user.DeletionDueAt,
+	}
+}
+
+// startSession issues the first token pair of a new refresh token family
+// for user and sets the auth cookies
+func (h *AuthHandler) startSession(c *gin.Context, user *models.User) (*LoginResponse, error) {
+	familyID := uuid.New()
+	refreshToken, jti, err := h.jwtService.GenerateRefreshToken(user.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := h.rbac.Grants(c.Request.Context(), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := h.jwtService.GenerateToken(user.ID, user.Email, grants.Roles, grants.Permissions, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.tokens.StartFamily(c.Request.Context(), user.ID, familyID, jti, clientOf(c)); err != nil {
+		return nil, err
+	}
+
+	h.setAuthCookies(c, accessToken, refreshToken)
+	return &LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.config.JWT.Expiration.Seconds()),
+		User:         loginUser(user, grants, h.media.AvatarURL(c.Request.Context(), user)),
+	}, nil
+}
+
+// setAuthCookies sets the HTTP-only token cookies
+func (h *AuthHandler) setAuthCookies(c *gin.Context, accessToken, refreshToken string) {
+	c.SetSameSite(h.getSameSiteMode())
+	c.SetCookie(
+		"access_token",
+		accessToken,
+		int(h.config.JWT.Expiration.Seconds()),
+		"/",
+		"",
+		h.config.Server.CookieSecure,
+		true, // HTTP-only
+	)
+	c.SetCookie(
+		"refresh_token",
+		refreshToken,
+		int(h.config.JWT.RefreshExpiration.Seconds()),
+		"/",
+		"",
+		h.config.Server.CookieSecure,
+		true,
+	)
+}
+
+// clearAuthCookies removes the token cookies
+func (h *AuthHandler) clearAuthCookies(c *gin.Context) {
+	c.SetSameSite(h.getSameSiteMode())
+	c.SetCookie("access_token", "", -1, "/", "", h.config.Server.CookieSecure, true)
+	c.SetCookie("refresh_token", "", -1, "/", "", h.config.Server.CookieSecure, true)
+}
+
+// clientOf describes the client making the request
+func clientOf(c *gin.Context) tokens.Client {
+	return tokens.Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
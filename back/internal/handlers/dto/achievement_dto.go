@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AchievementResponse represents an achievement or badge definition
+type AchievementResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Code        string    `json:"code"`
+	Kind        string    `json:"kind"` // achievement, badge
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Icon        string    `json:"icon"`
+	Rarity      string    `json:"rarity,omitempty"`
+	Points      int       `json:"points"`
+	Family      string    `json:"family,omitempty"`
+	Level       int       `json:"level,omitempty"`
+}
+
+// EarnedAchievementResponse represents an award the user holds
+type EarnedAchievementResponse struct {
+	AchievementResponse
+	EarnedAt   time.Time  `json:"earned_at"`
+	SourceType string     `json:"source_type,omitempty"`
+	SourceID   *uuid.UUID `json:"source_id,omitempty"`
+}
+
+// AchievementsResponse lists a user's awards
+type AchievementsResponse struct {
+	Achievements []EarnedAchievementResponse `json:"achievements"`
+	Badges       []EarnedAchievementResponse `json:"badges"`
+	TotalPoints  int                         `json:"total_points"`
+}
+
+// AchievementProgressResponse reports progress towards one definition
+type AchievementProgressResponse struct {
+	AchievementResponse
+	Requirement string     `json:"requirement"`
+	Current     float64    `json:"current"`
+	Target      float64    `json:"target"`
+	Progress    float64    `json:"progress"` // percentage
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
+}
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnswerResultResponse is returned after a practice answer. Unlike the
+// delivery DTOs it reveals the answer key, since the attempt is over.
+type AnswerResultResponse struct {
+	AnswerID          uuid.UUID        `json:"answer_id"`
+	QuestionID        uuid.UUID        `json:"question_id"`
+	QuestionType      string           `json:"question_type"`
+	IsCorrect         bool             `json:"is_correct"`
+	Credit            float64          `json:"credit"`
+	SelectedOptionIDs []uuid.UUID      `json:"selected_option_ids"`
+	CorrectOptionID   *uuid.UUID       `json:"correct_option_id,omitempty"` // first correct option, see CorrectOptionIDs
+	CorrectOptionIDs  []uuid.UUID      `json:"correct_option_ids"`
+	Options           []OptionResponse `json:"options"`
+	Explanation       string           `json:"explanation"`
+	ReferenceSource   string           `json:"reference_source"`
+	Mastery           *MasteryResponse `json:"mastery,omitempty"`
+}
+
+// TestQuestionReview represents one slot of a completed practice test: the
+// question as it was delivered, the candidate's selection and the answer key
+type TestQuestionReview struct {
+	Position          int              `json:"position"`
+	AnswerID          *uuid.UUID       `json:"answer_id,omitempty"`
+	SelectedOptionIDs []uuid.UUID      `json:"selected_option_ids"`
+	IsCorrect         bool             `json:"is_correct"`
+	Credit            float64          `json:"credit"`
+	TimeSpentSeconds  int              `json:"time_spent_seconds"`
+	CorrectOptionIDs  []uuid.UUID      `json:"correct_option_ids"`
+	Explanation       string           `json:"explanation"`
+	ReferenceSource   string           `json:"reference_source"`
+	Question          DeliveryQuestion `json:"question"`
+}
+
+// TestReviewResponse represents a completed practice test as returned by
+// ReviewTest
+type TestReviewResponse struct {
+	ID               uuid.UUID            `json:"id"`
+	TestType         string               `json:"test_type"`
+	Status           string               `json:"status"`
+	TotalQuestions   int                  `json:"total_questions"`
+	CorrectAnswers   int                  `json:"correct_answers"`
+	Score            float64              `json:"score"`
+	ScoringMode      string               `json:"scoring_mode,omitempty"`
+	TimeSpentSeconds int                  `json:"time_spent_seconds"`
+	TimeLimitMinutes int                  `json:"time_limit_minutes"`
+	StartedAt        time.Time            `json:"started_at"`
+	CompletedAt      *time.Time           `json:"completed_at,omitempty"`
+	AutoSubmitted    bool                 `json:"auto_submitted"`
+	Questions        []TestQuestionReview `json:"questions"`
+}
+
+// MasteryResponse reports a user's standing in one topic
+type MasteryResponse struct {
+	TopicID            uuid.UUID `json:"topic_id"`
+	TopicName          string    `json:"topic_name,omitempty"`
+	QuestionsAttempted int       `json:"questions_attempted"`
+	QuestionsCorrect   int       `json:"questions_correct"`
+	MasteryPercentage  float64   `json:"mastery_percentage"`
+	LastPracticed      time.Time `json:"last_practiced"`
+}
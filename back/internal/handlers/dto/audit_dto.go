@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/audit"
+	"github.com/nppe-pro/api/internal/models"
+)
+
+// ListAuditLogsFilter represents filters for browsing the audit log.
+// From and To are RFC 3339 timestamps; To is exclusive.
+type ListAuditLogsFilter struct {
+	ActorID    *uuid.UUID `form:"actor_id"`
+	Action     string     `form:"action"`
+	EntityType string     `form:"entity_type"`
+	EntityID   string     `form:"entity_id"`
+	RequestID  string     `form:"request_id"`
+	From       *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int        `form:"page" binding:"omitempty,min=1"`
+	PageSize   int        `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// Audit converts the request filter to the audit package's filter
+func (f *ListAuditLogsFilter) Audit() audit.Filter {
+	return audit.Filter{
+		ActorID:    f.ActorID,
+		Action:     f.Action,
+		EntityType: f.EntityType,
+		EntityID:   f.EntityID,
+		RequestID:  f.RequestID,
+		From:       f.From,
+		To:         f.To,
+	}
+}
+
+// GetPage returns page number (default 1)
+func (f *ListAuditLogsFilter) GetPage() int {
+	if f.Page < 1 {
+		return 1
+	}
+	return f.Page
+}
+
+// GetPageSize returns page size (default 50)
+func (f *ListAuditLogsFilter) GetPageSize() int {
+	if f.PageSize < 1 {
+		return 50
+	}
+	if f.PageSize > 100 {
+		return 100
+	}
+	return f.PageSize
+}
+
+// GetOffset calculates offset for pagination
+func (f *ListAuditLogsFilter) GetOffset() int {
+	return (f.GetPage() - 1) * f.GetPageSize()
+}
+
+// ListAuditLogsResponse represents a page of audit log entries
+type ListAuditLogsResponse struct {
+	Items    []models.AuditLog `json:"items"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
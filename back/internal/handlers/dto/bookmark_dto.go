@@ -0,0 +1,106 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/bookmarks"
+)
+
+// ErrInvalidFolderID is returned for a folder_id that is not a UUID
+var ErrInvalidFolderID = errors.New("invalid folder ID")
+
+// BookmarkRequest files a bookmark or annotates it. Omitted fields are left
+// as they are; a folder_id of "" takes the bookmark out of its folder.
+type BookmarkRequest struct {
+	FolderID *string `json:"folder_id"`
+	Note     *string `json:"note" binding:"omitempty,max=2000"`
+}
+
+// Change converts the request to a bookmarks.Change
+func (r *BookmarkRequest) Change() (bookmarks.Change, error) {
+	change := bookmarks.Change{Note: r.Note}
+	if r.FolderID != nil {
+		folderID := uuid.Nil
+		if *r.FolderID != "" {
+			id, err := uuid.Parse(*r.FolderID)
+			if err != nil {
+				return change, ErrInvalidFolderID
+			}
+			folderID = id
+		}
+		change.FolderID = &folderID
+	}
+	return change, nil
+}
+
+// ListBookmarksFilter selects a page of bookmarks. A folder_id of "none"
+// lists the bookmarks that are in no folder.
+type ListBookmarksFilter struct {
+	FolderID string `form:"folder_id"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// Bookmarks converts the request filter to the bookmarks package's filter
+func (f *ListBookmarksFilter) Bookmarks() (bookmarks.Filter, error) {
+	filter := bookmarks.Filter{Limit: f.GetPageSize(), Offset: (f.GetPage() - 1) * f.GetPageSize()}
+	switch f.FolderID {
+	case "":
+	case "none":
+		unfiled := uuid.Nil
+		filter.FolderID = &unfiled
+	default:
+		id, err := uuid.Parse(f.FolderID)
+		if err != nil {
+			return filter, ErrInvalidFolderID
+		}
+		filter.FolderID = &id
+	}
+	return filter, nil
+}
+
+// GetPage returns page number (default 1)
+func (f *ListBookmarksFilter) GetPage() int {
+	if f.Page < 1 {
+		return 1
+	}
+	return f.Page
+}
+
+// GetPageSize returns page size (default 20)
+func (f *ListBookmarksFilter) GetPageSize() int {
+	if f.PageSize < 1 {
+		return 20
+	}
+	if f.PageSize > 100 {
+		return 100
+	}
+	return f.PageSize
+}
+
+// BookmarkResponse represents a bookmark with its question in delivery
+// form, so the bookmark list never reveals the answer key
+type BookmarkResponse struct {
+	ID         uuid.UUID         `json:"id"`
+	QuestionID uuid.UUID         `json:"question_id"`
+	FolderID   *uuid.UUID        `json:"folder_id,omitempty"`
+	Note       string            `json:"note"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	Question   *DeliveryQuestion `json:"question,omitempty"`
+}
+
+// ListBookmarksResponse represents a page of bookmarks
+type ListBookmarksResponse struct {
+	Items    []BookmarkResponse `json:"items"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
+
+// BookmarkFolderRequest names a bookmark folder
+type BookmarkFolderRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
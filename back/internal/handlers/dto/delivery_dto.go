@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery DTOs are what a candidate sees while answering. They deliberately
+// have no is_correct, explanation or reference fields so the answer key can
+// never leak through the public question endpoints or an in-progress test.
+
+// DeliveryOption represents an answer choice without its correctness flag
+type DeliveryOption struct {
+	ID         uuid.UUID `json:"id"`
+	OptionText string    `json:"option_text"`
+	Position   int       `json:"position"`
+}
+
+// DeliveryQuestion represents a question as delivered to a candidate
+type DeliveryQuestion struct {
+	ID           uuid.UUID        `json:"id"`
+	Content      string           `json:"content"`
+	QuestionType string           `json:"question_type"`
+	Difficulty   string           `json:"difficulty"`
+	TopicID      uuid.UUID        `json:"topic_id"`
+	TopicName    string           `json:"topic_name,omitempty"`
+	TopicCode    string           `json:"topic_code,omitempty"`
+	SubTopicID   *uuid.UUID       `json:"sub_topic_id,omitempty"`
+	SubTopicName string           `json:"sub_topic_name,omitempty"`
+	SubTopicCode string           `json:"sub_topic_code,omitempty"`
+	Province     *string          `json:"province,omitempty"`
+	Options      []DeliveryOption `json:"options"`
+	IsBookmarked bool             `json:"is_bookmarked"` // always false for anonymous requests
+}
+
+// TestQuestionDelivery represents one slot of an in-progress practice test.
+// The candidate's own selection is echoed back, its correctness is not.
+type TestQuestionDelivery struct {
+	Position          int              `json:"position"`
+	AnswerID          *uuid.UUID       `json:"answer_id,omitempty"`
+	SelectedOptionIDs []uuid.UUID      `json:"selected_option_ids,omitempty"`
+	TimeSpentSeconds  int              `json:"time_spent_seconds"`
+	Question          DeliveryQuestion `json:"question"`
+}
+
+// TestDeliveryResponse represents a practice test as returned by GetTest.
+// The clock is owned by the server: clients should count down from
+// RemainingSeconds rather than from their own notion of StartedAt.
+type TestDeliveryResponse struct {
+	ID               uuid.UUID              `json:"id"`
+	TestType         string                 `json:"test_type"`
+	Status           string                 `json:"status"`
+	TotalQuestions   int                    `json:"total_questions"`
+	TimeLimitMinutes int                    `json:"time_limit_minutes"`
+	StartedAt        time.Time              `json:"started_at"`
+	CompletedAt      *time.Time             `json:"completed_at,omitempty"`
+	AutoSubmitted    bool                   `json:"auto_submitted"`
+	ServerTime       time.Time              `json:"server_time"`
+	ExpiresAt        *time.Time             `json:"expires_at,omitempty"`
+	RemainingSeconds *int                   `json:"remaining_seconds,omitempty"`
+	Questions        []TestQuestionDelivery `json:"questions"`
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/export"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/middleware"
+	"github.com/nppe-pro/api/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// ExportHandler serves personal data exports
+type ExportHandler struct {
+	exports *export.Service
+}
+
+func NewExportHandler(db *gorm.DB, store storage.Store, cfg *config.Config) *ExportHandler {
+	return &ExportHandler{exports: export.NewService(db, store, cfg)}
+}
+
+// RequestExport queues an export of the current user's data. The user is
+// notified when it is ready to download.
+func (h *ExportHandler) RequestExport(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	exp, err := h.exports.Request(c.Request.Context(), userID)
+	if errors.Is(err, export.ErrInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": "An export is already in progress", "export": exp})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to request data export for %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request export"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, exp)
+}
+
+// ListExports returns the current user's exports
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	exports, err := h.exports.List(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+// GetExport returns one export, with a short-lived download_url once it
+// is ready
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	exp, err := h.exports.Get(c.Request.Context(), userID, id)
+	if errors.Is(err, export.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch export"})
+		return
+	}
+
+	resp := gin.H{"export": exp}
+	if exp.Status == models.ExportStatusReady {
+		url, err := h.exports.URL(c.Request.Context(), exp)
+		if err != nil && !errors.Is(err, export.ErrNotReady) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign download link"})
+			return
+		}
+		if url != "" {
+			resp["download_url"] = url
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/lockout"
+	"github.com/nppe-pro/api/internal/mfa"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/database"
+	"gorm.io/gorm"
+)
+
+// MFAHandler lets a signed-in user manage their second factor. Wrong codes
+// are throttled like logins, so a stolen session cannot guess its way to
+// disabling MFA.
+type MFAHandler struct {
+	db      *gorm.DB
+	redis   *database.RedisClient
+	mfa     *mfa.Service
+	lockout *lockout.Guard
+}
+
+func NewMFAHandler(db *gorm.DB, redis *database.RedisClient, cfg *config.Config) *MFAHandler {
+	return &MFAHandler{
+		db:      db,
+		redis:   redis,
+		mfa:     mfa.NewService(db, redis, cfg),
+		lockout: lockout.NewGuard(db, redis, cfg),
+	}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// GetMFAStatus reports whether MFA is on and how many recovery codes are left
+func (h *MFAHandler) GetMFAStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	left, err := h.mfa.RecoveryCodesLeft(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch MFA status"})
+		return
+	}
+
+	required, err := h.mfa.Required(c.Request.Context(), user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch MFA status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":             mfa.Enabled(user),
+		"enabled_at":          user.MFAEnabledAt,
+		"required":            required,
+		"recovery_codes_left": left,
+	})
+}
+
+// EnrollMFA generates a new secret. It becomes active once ActivateMFA
+// receives a code from the authenticator.
+func (h *MFAHandler) EnrollMFA(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	secret, uri, err := h.mfa.Enroll(c.Request.Context(), user)
+	if err != nil {
+		if errors.Is(err, mfa.ErrAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": "MFA is already enabled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// ActivateMFA confirms enrolment and returns the recovery codes, which are
+// only ever shown here
+func (h *MFAHandler) ActivateMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var codes []string
+	if !h.withCode(c, user, "Failed to activate MFA", func() (err error) {
+		codes, err = h.mfa.Activate(c.Request.Context(), user, req.Code)
+		return err
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var codes []string
+	if !h.withCode(c, user, "Failed to regenerate recovery codes", func() (err error) {
+		codes, err = h.mfa.RegenerateRecoveryCodes(c.Request.Context(), user, req.Code)
+		return err
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableMFA turns MFA off after checking a current code
+func (h *MFAHandler) DisableMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if !h.withCode(c, user, "Failed to disable MFA", func() error {
+		return h.mfa.Disable(c.Request.Context(), user, req.Code)
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA disabled"})
+}
+
+func (h *MFAHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", userID.(uuid.UUID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// withCode runs an action that checks a code of user, counting wrong codes
+// in the mfa lockout scope. It responds and reports false when the action
+// fails or the user has to wait.
+func (h *MFAHandler) withCode(c *gin.Context, user *models.User, fallback string, action func() error) bool {
+	attempt := lockout.Attempt{Account: user.Email, IP: c.ClientIP(), UserID: &user.ID}
+	if throttled(c, h.lockout, lockout.ScopeMFA, attempt) {
+		return false
+	}
+
+	if err := action(); err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			recordFailure(c, h.lockout, lockout.ScopeMFA, attempt)
+		}
+		h.respondError(c, err, fallback)
+		return false
+	}
+
+	if err := h.lockout.Succeed(c.Request.Context(), lockout.ScopeMFA, user.Email); err != nil {
+		log.Printf("⚠️ Failed to clear MFA failures for %s: %v", user.ID, err)
+	}
+	return true
+}
+
+func (h *MFAHandler) respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+	case errors.Is(err, mfa.ErrNotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "MFA is not set up"})
+	case errors.Is(err, mfa.ErrAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "MFA is already enabled"})
+	case errors.Is(err, mfa.ErrRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "MFA is required for this account"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
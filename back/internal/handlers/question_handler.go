@@ -1,371 +1,621 @@
-package handlers
-
-import (
-	"net/http"
-	"strconv"
-
-	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"github.com/nppe-pro/api/internal/handlers/dto"
-	"github.com/nppe-pro/api/internal/models"
-	"github.com/nppe-pro/api/internal/repo"
-	"github.com/nppe-pro/api/pkg/database"
-	"gorm.io/gorm"
-)
-
-type QuestionHandler struct {
-	db    *gorm.DB
-	redis *database.RedisClient
-	repo  *repo.QuestionRepository
-}
-
-func NewQuestionHandler(db *gorm.DB, redis *database.RedisClient) *QuestionHandler {
-	return &QuestionHandler{
-		db:    db,
-		redis: redis,
-		repo:  repo.NewQuestionRepository(db),
-	}
-}
-
-// GetQuestions returns a list of questions (public endpoint - only active)
-func (h *QuestionHandler) GetQuestions(c *gin.Context) {
-	var questions []models.Question
-	query := h.db.Preload("Topic").Preload("SubTopic").Preload("Options").Where("is_active = ?", true)
-
-	// Filter by topic
-	if topicID := c.Query("topic_id"); topicID != "" {
-		query = query.Where("topic_id = ?", topicID)
-	}
-
-	// Filter by subtopic
-	if subTopicID := c.Query("sub_topic_id"); subTopicID != "" {
-		query = query.Where("sub_topic_id = ?", subTopicID)
-	}
-
-	// Filter by difficulty
-	if difficulty := c.Query("difficulty"); difficulty != "" {
-		query = query.Where("difficulty = ?", difficulty)
-	}
-
-	// Pagination
-	limit := 20
-	if l := c.Query("limit"); l != "" {
-		if parsedLimit, err := strconv.Atoi(l); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
-			limit = parsedLimit
-		}
-	}
-
-	offset := 0
-	if o := c.Query("offset"); o != "" {
-		if parsedOffset, err := strconv.Atoi(o); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
-	}
-
-	var total int64
-	query.Model(&models.Question{}).Count(&total)
-
-	if err := query.Limit(limit).Offset(offset).Find(&questions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
-		return
-	}
-
-	// Add topic/subtopic codes to response
-	type QuestionResponse struct {
-		models.Question
-		TopicCode    string `json:"topic_code,omitempty"`
-		SubTopicCode string `json:"sub_topic_code,omitempty"`
-	}
-
-	var response []QuestionResponse
-	for _, q := range questions {
-		qr := QuestionResponse{Question: q}
-		if q.Topic != nil {
-			qr.TopicCode = q.Topic.Code
-		}
-		if q.SubTopic != nil {
-			qr.SubTopicCode = q.SubTopic.Code
-		}
-		response = append(response, qr)
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"questions": response,
-		"total":     total,
-		"limit":     limit,
-		"offset":    offset,
-	})
-}
-
-// GetQuestion returns a single question (public endpoint)
-func (h *QuestionHandler) GetQuestion(c *gin.Context) {
-	id := c.Param("id")
-	var question models.Question
-
-	if err := h.db.Preload("Topic").Preload("SubTopic").Preload("Options").
-		Where("id = ? AND is_active = ?", id, true).
-		First(&question).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question"})
-		return
-	}
-
-	c.JSON(http.StatusOK, question)
-}
-
-// SubmitAnswer submits an answer to a question
-func (h *QuestionHandler) SubmitAnswer(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Submit answer endpoint"})
-}
-
-// BookmarkQuestion bookmarks a question
-func (h *QuestionHandler) BookmarkQuestion(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Bookmark question endpoint"})
-}
-
-// RemoveBookmark removes a bookmark
-func (h *QuestionHandler) RemoveBookmark(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Remove bookmark endpoint"})
-}
-
-// GetTopics returns all topics
-func (h *QuestionHandler) GetTopics(c *gin.Context) {
-	var topics []models.Topic
-
-	if err := h.db.Order("\"order\" ASC").Find(&topics).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topics"})
-		return
-	}
-
-	c.JSON(http.StatusOK, topics)
-}
-
-// GetTopic returns a single topic with its subtopics
-func (h *QuestionHandler) GetTopic(c *gin.Context) {
-	id := c.Param("id")
-	var topic models.Topic
-
-	if err := h.db.First(&topic, "id = ?", id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topic"})
-		return
-	}
-
-	var subTopics []models.SubTopic
-	if err := h.db.Where("topic_id = ?", id).Order("\"order\" ASC").Find(&subTopics).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subtopics"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":          topic.ID,
-		"name":        topic.Name,
-		"code":        topic.Code,
-		"description": topic.Description,
-		"weight":      topic.Weight,
-		"order":       topic.Order,
-		"sub_topics":  subTopics,
-		"created_at":  topic.CreatedAt,
-		"updated_at":  topic.UpdatedAt,
-	})
-}
-
-// ===== ADMIN ENDPOINTS =====
-
-// AdminListQuestions lists all questions with filters (admin only)
-func (h *QuestionHandler) AdminListQuestions(c *gin.Context) {
-	var filter dto.ListQuestionsFilter
-	if err := c.ShouldBindQuery(&filter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	questions, total, err := h.repo.ListQuestions(c.Request.Context(), &filter)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
-		return
-	}
-
-	// Build response
-	items := make([]dto.QuestionResponse, len(questions))
-	for i, q := range questions {
-		items[i] = buildQuestionResponse(&q)
-	}
-
-	c.JSON(http.StatusOK, dto.ListQuestionsResponse{
-		Items:    items,
-		Total:    total,
-		Page:     filter.GetPage(),
-		PageSize: filter.GetPageSize(),
-	})
-}
-
-// AdminGetQuestion gets a single question with all details (admin only)
-func (h *QuestionHandler) AdminGetQuestion(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
-		return
-	}
-
-	question, err := h.repo.GetQuestion(c.Request.Context(), id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
-		return
-	}
-
-	c.JSON(http.StatusOK, buildQuestionResponse(question))
-}
-
-// CreateQuestion creates a new question (admin only)
-func (h *QuestionHandler) CreateQuestion(c *gin.Context) {
-	var req dto.CreateQuestionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Validate request
-	if err := req.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	question, err := h.repo.CreateQuestionTx(c.Request.Context(), &req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusCreated, buildQuestionResponse(question))
-}
-
-// UpdateQuestion updates a question (admin only)
-func (h *QuestionHandler) UpdateQuestion(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
-		return
-	}
-
-	var req dto.UpdateQuestionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	// Validate request
-	if err := req.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	question, err := h.repo.UpdateQuestionTx(c.Request.Context(), id, &req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update question",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, buildQuestionResponse(question))
-}
-
-// DeleteQuestion deletes a question (admin only)
-func (h *QuestionHandler) DeleteQuestion(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
-		return
-	}
-
-	if err := h.repo.DeleteQuestion(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusNoContent, nil)
-}
-
-// BulkOperations performs bulk operations on questions (admin only)
-func (h *QuestionHandler) BulkOperations(c *gin.Context) {
-	var req dto.BulkOperationRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	var err error
-	switch req.Operation {
-	case "activate":
-		err = h.repo.BulkUpdateStatus(c.Request.Context(), req.IDs, true)
-	case "deactivate":
-		err = h.repo.BulkUpdateStatus(c.Request.Context(), req.IDs, false)
-	case "delete":
-		err = h.repo.BulkDelete(c.Request.Context(), req.IDs)
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation"})
-		return
-	}
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Bulk operation completed successfully",
-		"count":   len(req.IDs),
-	})
-}
-
-// Helper function to build question response DTO
-func buildQuestionResponse(q *models.Question) dto.QuestionResponse {
-	resp := dto.QuestionResponse{
-		ID:              q.ID,
-		Content:         q.Content,
-		QuestionType:    q.QuestionType,
-		Difficulty:      q.Difficulty,
-		TopicID:         q.TopicID,
-		SubTopicID:      q.SubTopicID,
-		Province:        q.Province,
-		Explanation:     q.Explanation,
-		ReferenceSource: q.ReferenceSource,
-		IsActive:        q.IsActive,
-		CreatedAt:       q.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:       q.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	if q.Topic != nil {
-		resp.TopicName = q.Topic.Name
-	}
-
-	if q.SubTopic != nil {
-		resp.SubTopicName = q.SubTopic.Name
-	}
-
-	resp.Options = make([]dto.OptionResponse, len(q.Options))
-	for i, opt := range q.Options {
-		resp.Options[i] = dto.OptionResponse{
-			ID:         opt.ID,
-			OptionText: opt.OptionText,
-			IsCorrect:  opt.IsCorrect,
-			Position:   opt.Position,
-		}
-	}
-
-	return resp
-}
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/bookmarks"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/grading"
+	"github.com/nppe-pro/api/internal/handlers/dto"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/internal/practice"
+	"github.com/nppe-pro/api/internal/rbac"
+	"github.com/nppe-pro/api/internal/repo"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+type QuestionHandler struct {
+	db        *gorm.DB
+	redis     *database.RedisClient
+	repo      *repo.QuestionRepository
+	practice  *practice.Service
+	bookmarks *bookmarks.Service
+}
+
+func NewQuestionHandler(db *gorm.DB, redis *database.RedisClient, cfg *config.Config, bus *events.Bus) *QuestionHandler {
+	return &QuestionHandler{
+		db:        db,
+		redis:     redis,
+		repo:      repo.NewQuestionRepository(db),
+		practice:  practice.NewService(db, cfg, bus),
+		bookmarks: bookmarks.NewService(db),
+	}
+}
+
+// GetQuestions returns a list of questions (public endpoint - only active).
+// Options are delivered without their correctness flag.
+func (h *QuestionHandler) GetQuestions(c *gin.Context) {
+	var questions []models.Question
+	query := h.db.Preload("Topic").Preload("SubTopic").
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("is_active = ?", true)
+
+	// Filter by topic
+	if topicID := c.Query("topic_id"); topicID != "" {
+		query = query.Where("topic_id = ?", topicID)
+	}
+
+	// Filter by subtopic
+	if subTopicID := c.Query("sub_topic_id"); subTopicID != "" {
+		query = query.Where("sub_topic_id = ?", subTopicID)
+	}
+
+	// Filter by difficulty
+	if difficulty := c.Query("difficulty"); difficulty != "" {
+		query = query.Where("difficulty = ?", difficulty)
+	}
+
+	// Pagination
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsedLimit, err := strconv.Atoi(l); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+
+	offset := 0
+	if o := c.Query("offset"); o != "" {
+		if parsedOffset, err := strconv.Atoi(o); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	var total int64
+	query.Model(&models.Question{}).Count(&total)
+
+	if err := query.Limit(limit).Offset(offset).Find(&questions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
+		return
+	}
+
+	ids := make([]uuid.UUID, len(questions))
+	for i := range questions {
+		ids[i] = questions[i].ID
+	}
+	marked := bookmarkedSet(c, h.bookmarks, ids)
+
+	response := make([]dto.DeliveryQuestion, len(questions))
+	for i := range questions {
+		response[i] = buildDeliveryQuestion(&questions[i])
+		response[i].IsBookmarked = marked[questions[i].ID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"questions": response,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// GetQuestion returns a single question (public endpoint).
+// The answer key is only revealed once the user submits an answer.
+func (h *QuestionHandler) GetQuestion(c *gin.Context) {
+	id := c.Param("id")
+	var question models.Question
+
+	if err := h.db.Preload("Topic").Preload("SubTopic").
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("id = ? AND is_active = ?", id, true).
+		First(&question).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question"})
+		return
+	}
+
+	resp := buildDeliveryQuestion(&question)
+	resp.IsBookmarked = bookmarkedSet(c, h.bookmarks, []uuid.UUID{question.ID})[question.ID]
+	c.JSON(http.StatusOK, resp)
+}
+
+// SubmitAnswer grades a practice-mode answer, records it in the user's
+// history and returns the answer key with the explanation
+func (h *QuestionHandler) SubmitAnswer(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	questionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	var req SubmitAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	selected, err := req.OptionIDs()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid option ID"})
+		return
+	}
+
+	result, err := h.practice.Answer(c.Request.Context(), practice.Attempt{
+		UserID:           userID.(uuid.UUID),
+		QuestionID:       questionID,
+		Selected:         selected,
+		TimeSpentSeconds: req.TimeSpentSeconds,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, practice.ErrQuestionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		case errors.Is(err, practice.ErrQuestionInTest):
+			c.JSON(http.StatusConflict, gin.H{"error": "This question is part of a test in progress, answer it in the test"})
+		case errors.Is(err, grading.ErrNoSelection),
+			errors.Is(err, grading.ErrTooManySelected),
+			errors.Is(err, grading.ErrOptionNotOnQuestion):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit answer"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, buildAnswerResult(result))
+}
+
+// BookmarkQuestion bookmarks a question, optionally filing it in a folder
+// with a note. Bookmarking it again updates the folder and note given.
+func (h *QuestionHandler) BookmarkQuestion(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	questionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	// The body is optional
+	var req dto.BookmarkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	change, err := req.Change()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	bookmark, created, err := h.bookmarks.Save(c.Request.Context(), userID, questionID, change)
+	if err != nil {
+		switch {
+		case errors.Is(err, bookmarks.ErrQuestionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		case errors.Is(err, bookmarks.ErrFolderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark question"})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, buildBookmarkResponse(bookmark))
+}
+
+// RemoveBookmark removes a bookmark together with its note
+func (h *QuestionHandler) RemoveBookmark(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	questionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	if err := h.bookmarks.Remove(c.Request.Context(), userID, questionID); err != nil {
+		if errors.Is(err, bookmarks.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed"})
+}
+
+// GetTopics returns all topics
+func (h *QuestionHandler) GetTopics(c *gin.Context) {
+	var topics []models.Topic
+
+	if err := h.db.Order("\"order\" ASC").Find(&topics).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, topics)
+}
+
+// GetTopic returns a single topic with its subtopics
+func (h *QuestionHandler) GetTopic(c *gin.Context) {
+	id := c.Param("id")
+	var topic models.Topic
+
+	if err := h.db.First(&topic, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Topic not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topic"})
+		return
+	}
+
+	var subTopics []models.SubTopic
+	if err := h.db.Where("topic_id = ?", id).Order("\"order\" ASC").Find(&subTopics).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subtopics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":          topic.ID,
+		"name":        topic.Name,
+		"code":        topic.Code,
+		"description": topic.Description,
+		"weight":      topic.Weight,
+		"order":       topic.Order,
+		"sub_topics":  subTopics,
+		"created_at":  topic.CreatedAt,
+		"updated_at":  topic.UpdatedAt,
+	})
+}
+
+// ===== ADMIN ENDPOINTS =====
+
+// AdminListQuestions lists all questions with filters (admin only)
+func (h *QuestionHandler) AdminListQuestions(c *gin.Context) {
+	var filter dto.ListQuestionsFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	questions, total, err := h.repo.ListQuestions(c.Request.Context(), &filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
+		return
+	}
+
+	// Build response
+	items := make([]dto.QuestionResponse, len(questions))
+	for i, q := range questions {
+		items[i] = buildQuestionResponse(&q)
+	}
+
+	c.JSON(http.StatusOK, dto.ListQuestionsResponse{
+		Items:    items,
+		Total:    total,
+		Page:     filter.GetPage(),
+		PageSize: filter.GetPageSize(),
+	})
+}
+
+// AdminGetQuestion gets a single question with all details (admin only)
+func (h *QuestionHandler) AdminGetQuestion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	question, err := h.repo.GetQuestion(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildQuestionResponse(question))
+}
+
+// CreateQuestion creates a new question (admin only)
+func (h *QuestionHandler) CreateQuestion(c *gin.Context) {
+	var req dto.CreateQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	question, err := h.repo.CreateQuestionTx(c.Request.Context(), &req, auditActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, buildQuestionResponse(question))
+}
+
+// UpdateQuestion updates a question (admin only)
+func (h *QuestionHandler) UpdateQuestion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	var req dto.UpdateQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	question, err := h.repo.UpdateQuestionTx(c.Request.Context(), id, &req, auditActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update question",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, buildQuestionResponse(question))
+}
+
+// DeleteQuestion deletes a question (admin only)
+func (h *QuestionHandler) DeleteQuestion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	if err := h.repo.DeleteQuestion(c.Request.Context(), id, auditActor(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// BulkOperations performs bulk operations on questions (admin only)
+func (h *QuestionHandler) BulkOperations(c *gin.Context) {
+	var req dto.BulkOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var err error
+	switch req.Operation {
+	case "activate":
+		err = h.repo.BulkUpdateStatus(c.Request.Context(), req.IDs, true, auditActor(c))
+	case "deactivate":
+		err = h.repo.BulkUpdateStatus(c.Request.Context(), req.IDs, false, auditActor(c))
+	case "delete":
+		// The route only requires questions:publish
+		if claims, ok := middleware.GetClaims(c); !ok || !claims.HasPermission(rbac.PermQuestionsDelete) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission required: " + rbac.PermQuestionsDelete})
+			return
+		}
+		err = h.repo.BulkDelete(c.Request.Context(), req.IDs, auditActor(c))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation"})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bulk operation completed successfully",
+		"count":   len(req.IDs),
+	})
+}
+
+// Helper function to build question response DTO
+func buildQuestionResponse(q *models.Question) dto.QuestionResponse {
+	resp := dto.QuestionResponse{
+		ID:              q.ID,
+		Content:         q.Content,
+		QuestionType:    q.QuestionType,
+		Difficulty:      q.Difficulty,
+		TopicID:         q.TopicID,
+		SubTopicID:      q.SubTopicID,
+		Province:        q.Province,
+		Explanation:     q.Explanation,
+		ReferenceSource: q.ReferenceSource,
+		IsActive:        q.IsActive,
+		CreatedAt:       q.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:       q.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	if q.Topic != nil {
+		resp.TopicName = q.Topic.Name
+	}
+
+	if q.SubTopic != nil {
+		resp.SubTopicName = q.SubTopic.Name
+	}
+
+	resp.Options = make([]dto.OptionResponse, len(q.Options))
+	for i, opt := range q.Options {
+		resp.Options[i] = dto.OptionResponse{
+			ID:         opt.ID,
+			OptionText: opt.OptionText,
+			IsCorrect:  opt.IsCorrect,
+			Position:   opt.Position,
+		}
+	}
+
+	return resp
+}
+
+// bookmarkedSet looks up which of the questions the current user has
+// bookmarked. Anonymous requests get an empty set, and so do failed
+// lookups: the flag is not worth failing the request over.
+func bookmarkedSet(c *gin.Context, svc *bookmarks.Service, questionIDs []uuid.UUID) map[uuid.UUID]bool {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return nil
+	}
+	marked, err := svc.Marked(c.Request.Context(), userID, questionIDs)
+	if err != nil {
+		log.Printf("⚠️ Failed to look up bookmarks of user %s: %v", userID, err)
+		return nil
+	}
+	return marked
+}
+
+// buildBookmarkResponse converts a bookmark into its response, with the
+// question in delivery form when it was loaded
+func buildBookmarkResponse(b *models.UserBookmark) dto.BookmarkResponse {
+	resp := dto.BookmarkResponse{
+		ID:         b.ID,
+		QuestionID: b.QuestionID,
+		FolderID:   b.FolderID,
+		Note:       b.Note,
+		CreatedAt:  b.CreatedAt,
+		UpdatedAt:  b.UpdatedAt,
+	}
+	if b.Question != nil {
+		question := buildDeliveryQuestion(b.Question)
+		question.IsBookmarked = true
+		resp.Question = &question
+	}
+	return resp
+}
+
+// buildDeliveryQuestion converts a question into its candidate-facing form,
+// dropping is_correct, explanation and reference source
+func buildDeliveryQuestion(q *models.Question) dto.DeliveryQuestion {
+	resp := dto.DeliveryQuestion{
+		ID:           q.ID,
+		Content:      q.Content,
+		QuestionType: q.QuestionType,
+		Difficulty:   q.Difficulty,
+		TopicID:      q.TopicID,
+		SubTopicID:   q.SubTopicID,
+		Province:     q.Province,
+	}
+
+	if q.Topic != nil {
+		resp.TopicName = q.Topic.Name
+		resp.TopicCode = q.Topic.Code
+	}
+
+	if q.SubTopic != nil {
+		resp.SubTopicName = q.SubTopic.Name
+		resp.SubTopicCode = q.SubTopic.Code
+	}
+
+	resp.Options = make([]dto.DeliveryOption, len(q.Options))
+	for i, opt := range q.Options {
+		resp.Options[i] = dto.DeliveryOption{
+			ID:         opt.ID,
+			OptionText: opt.OptionText,
+			Position:   opt.Position,
+		}
+	}
+
+	return resp
+}
+
+// buildAnswerResult converts a graded practice attempt into its response,
+// including the answer key
+func buildAnswerResult(r *practice.Result) dto.AnswerResultResponse {
+	q := r.Question
+	resp := dto.AnswerResultResponse{
+		AnswerID:          r.Answer.ID,
+		QuestionID:        q.ID,
+		QuestionType:      q.QuestionType,
+		IsCorrect:         r.Outcome.Correct,
+		Credit:            r.Outcome.Credit,
+		SelectedOptionIDs: r.Answer.SelectedOptionIDs,
+		CorrectOptionIDs:  grading.CorrectOptionIDs(q),
+		Explanation:       q.Explanation,
+		ReferenceSource:   q.ReferenceSource,
+	}
+
+	if len(resp.CorrectOptionIDs) > 0 {
+		resp.CorrectOptionID = &resp.CorrectOptionIDs[0]
+	}
+
+	resp.Options = make([]dto.OptionResponse, len(q.Options))
+	for i, opt := range q.Options {
+		resp.Options[i] = dto.OptionResponse{
+			ID:         opt.ID,
+			OptionText: opt.OptionText,
+			IsCorrect:  opt.IsCorrect,
+			Position:   opt.Position,
+		}
+	}
+
+	if m := r.Mastery; m != nil {
+		resp.Mastery = &dto.MasteryResponse{
+			TopicID:            m.TopicID,
+			QuestionsAttempted: m.QuestionsAttempted,
+			QuestionsCorrect:   m.QuestionsCorrect,
+			MasteryPercentage:  m.MasteryPercentage,
+			LastPracticed:      m.LastPracticed,
+		}
+		if q.Topic != nil {
+			resp.Mastery.TopicName = q.Topic.Name
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/internal/rbac"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/tokens"
+	"gorm.io/gorm"
+)
+
+// RoleHandler manages staff roles
+type RoleHandler struct {
+	db     *gorm.DB
+	redis  *database.RedisClient
+	rbac   *rbac.Service
+	tokens *tokens.Store
+}
+
+func NewRoleHandler(db *gorm.DB, redis *database.RedisClient, tokenStore *tokens.Store) *RoleHandler {
+	return &RoleHandler{
+		db:     db,
+		redis:  redis,
+		rbac:   rbac.NewService(db),
+		tokens: tokenStore,
+	}
+}
+
+type SetRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
+
+// ListRoles returns every role with its permissions
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.rbac.Roles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// GetUserRoles returns a user's roles and permissions
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	grants, err := h.rbac.Grants(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+// SetUserRoles replaces a user's roles. The user's sessions are revoked so
+// the new grants apply from their next login rather than their next
+// token refresh.
+func (h *RoleHandler) SetUserRoles(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req SetRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	grants, err := h.rbac.SetRoles(ctx, userID, req.Roles, auditActor(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, rbac.ErrUnknownRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		case errors.Is(err, rbac.ErrLastSuperAdmin):
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last super admin"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update roles"})
+		}
+		return
+	}
+
+	if err := h.tokens.RevokeAll(ctx, userID); err != nil {
+		log.Printf("⚠️ Failed to revoke sessions of %s after role change: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
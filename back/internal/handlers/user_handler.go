@@ -1,124 +1,933 @@
-package handlers
-
-import (
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-	"github.com/nppe-pro/api/config"
-	"github.com/nppe-pro/api/internal/models"
-	"github.com/nppe-pro/api/pkg/database"
-	"github.com/nppe-pro/api/pkg/middleware"
-	"gorm.io/gorm"
-)
-
-type UserHandler struct {
-	db     *gorm.DB
-	redis  *database.RedisClient
-	config *config.Config
-}
-
-func NewUserHandler(db *gorm.DB, redis *database.RedisClient, cfg *config.Config) *UserHandler {
-	return &UserHandler{
-		db:     db,
-		redis:  redis,
-		config: cfg,
-	}
-}
-
-// GetProfile returns the current user's profile
-func (h *UserHandler) GetProfile(c *gin.Context) {
-	userID, err := middleware.GetUserID(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Get profile endpoint",
-		"user_id": userID,
-	})
-}
-
-// UpdateProfile updates user profile
-func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Update profile endpoint"})
-}
-
-// DeleteAccount soft deletes user account
-func (h *UserHandler) DeleteAccount(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Delete account endpoint"})
-}
-
-// UploadAvatar handles avatar upload
-func (h *UserHandler) UploadAvatar(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Upload avatar endpoint"})
-}
-
-// GetBookmarks returns user's bookmarked questions
-func (h *UserHandler) GetBookmarks(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Get bookmarks endpoint"})
-}
-
-// GetTestHistory returns user's practice test history
-func (h *UserHandler) GetTestHistory(c *gin.Context) {
-	userID, err := middleware.GetUserID(c)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	var tests []models.PracticeTest
-	if err := h.db.Where("user_id = ?", userID).
-		Order("started_at DESC").
-		Find(&tests).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test history"})
-		return
-	}
-
-	c.JSON(http.StatusOK, tests)
-}
-
-// GetStudyPath returns user's study path
-func (h *UserHandler) GetStudyPath(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Get study path endpoint"})
-}
-
-// CreateSubscription creates a new subscription
-func (h *UserHandler) CreateSubscription(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Create subscription endpoint"})
-}
-
-// GetSubscription returns current subscription
-func (h *UserHandler) GetSubscription(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Get subscription endpoint"})
-}
-
-// CancelSubscription cancels the subscription
-func (h *UserHandler) CancelSubscription(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Cancel subscription endpoint"})
-}
-
-// GetNotifications returns user notifications
-func (h *UserHandler) GetNotifications(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Get notifications endpoint"})
-}
-
-// MarkNotificationRead marks a notification as read
-func (h *UserHandler) MarkNotificationRead(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Mark notification read endpoint"})
-}
-
-// UpdateNotificationSettings updates notification settings
-func (h *UserHandler) UpdateNotificationSettings(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Update notification settings endpoint"})
-}
-
-// ListUsers returns all users (admin only)
-func (h *UserHandler) ListUsers(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "List users endpoint (admin)"})
-}
-
-// StripeWebhook handles Stripe webhook events
-func (h *UserHandler) StripeWebhook(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"received": true})
-}
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/bookmarks"
+	"github.com/nppe-pro/api/internal/deletion"
+	"github.com/nppe-pro/api/internal/email"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/handlers/dto"
+	"github.com/nppe-pro/api/internal/lockout"
+	"github.com/nppe-pro/api/internal/media"
+	"github.com/nppe-pro/api/internal/mfa"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/internal/province"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/middleware"
+	"github.com/nppe-pro/api/pkg/storage"
+	"github.com/nppe-pro/api/pkg/tokens"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type UserHandler struct {
+	db        *gorm.DB
+	redis     *database.RedisClient
+	tokens    *tokens.Store
+	email     *email.Service
+	lockout   *lockout.Guard
+	mfa       *mfa.Service
+	media     *media.Service
+	deletion  *deletion.Service
+	bookmarks *bookmarks.Service
+	config    *config.Config
+	bus       *events.Bus
+}
+
+func NewUserHandler(db *gorm.DB, redis *database.RedisClient, tokenStore *tokens.Store, store storage.Store, cfg *config.Config, bus *events.Bus) *UserHandler {
+	return &UserHandler{
+		db:        db,
+		redis:     redis,
+		tokens:    tokenStore,
+		email:     email.NewService(db, cfg),
+		lockout:   lockout.NewGuard(db, redis, cfg),
+		mfa:       mfa.NewService(db, redis, cfg),
+		media:     media.NewService(store, cfg),
+		deletion:  deletion.NewService(db, tokenStore, store, cfg),
+		bookmarks: bookmarks.NewService(db),
+		config:    cfg,
+		bus:       bus,
+	}
+}
+
+var errEmailTaken = errors.New("email already registered")
+
+// UpdateProfileRequest changes any subset of the profile. Changing the
+// email or password needs the current password; accounts created through
+// Google have none until they set one with the reset flow, and confirm an
+// email change with an MFA code or a recent sign-in instead.
+type UpdateProfileRequest struct {
+	FirstName       *string `json:"first_name" binding:"omitempty,max=100"`
+	LastName        *string `json:"last_name" binding:"omitempty,max=100"`
+	Province        *string `json:"province"`
+	ExamDate        *string `json:"exam_date"`  // YYYY-MM-DD or RFC 3339, "" clears it
+	Timezone        *string `json:"timezone"`   // IANA name, "" falls back to the default
+	AvatarURL       *string `json:"avatar_url"` // only "" is accepted, to remove the avatar
+	Email           *string `json:"email" binding:"omitempty,email,max=255"`
+	NewPassword     *string `json:"new_password" binding:"omitempty,min=8,max=72"`
+	CurrentPassword string  `json:"current_password"`
+	MFACode         string  `json:"mfa_code"`
+}
+
+// GetProfile returns the current user's profile
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.profileResponse(c, user))
+}
+
+// UpdateProfile updates the current user's profile. A new email address
+// only replaces the current one once it is verified; a new password signs
+// out every other session.
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	var changed []string
+
+	for _, name := range []struct {
+		value  *string
+		column string
+		field  string
+		label  string
+	}{
+		{req.FirstName, "first_name", user.FirstName, "First name"},
+		{req.LastName, "last_name", user.LastName, "Last name"},
+	} {
+		if name.value == nil {
+			continue
+		}
+		v := strings.TrimSpace(*name.value)
+		if v == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": name.label + " cannot be empty"})
+			return
+		}
+		if v != name.field {
+			updates[name.column] = v
+			changed = append(changed, name.column)
+		}
+	}
+
+	if req.Province != nil {
+		regulator, ok := province.Lookup(*req.Province)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown province"})
+			return
+		}
+		if regulator.Code != user.Province {
+			updates["province"] = regulator.Code
+			changed = append(changed, "province")
+		}
+	}
+
+	if req.ExamDate != nil {
+		examDate, err := parseExamDate(*req.ExamDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !sameDate(examDate, user.ExamDate) {
+			updates["exam_date"] = examDate
+			changed = append(changed, "exam_date")
+		}
+	}
+
+	if req.Timezone != nil {
+		tz := strings.TrimSpace(*req.Timezone)
+		if tz != "" {
+			if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone"})
+				return
+			}
+		}
+		if tz != user.Timezone {
+			updates["timezone"] = tz
+			changed = append(changed, "timezone")
+		}
+	}
+
+	if req.AvatarURL != nil {
+		if *req.AvatarURL != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Upload avatars through /users/me/avatar"})
+			return
+		}
+		if user.AvatarURL != "" || user.AvatarKey != "" {
+			updates["avatar_url"] = ""
+			updates["avatar_key"] = ""
+			changed = append(changed, "avatar_url")
+		}
+	}
+
+	var newEmail string
+	if req.Email != nil && !strings.EqualFold(strings.TrimSpace(*req.Email), user.Email) {
+		newEmail = strings.TrimSpace(*req.Email)
+	}
+
+	// Changing the credentials needs the user to sign in again
+	if newEmail != "" || req.NewPassword != nil {
+		if req.NewPassword != nil && user.PasswordHash == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "This account has no password yet; set one through the password reset flow"})
+			return
+		}
+		if !h.reauthenticate(c, user, req.CurrentPassword, req.MFACode) {
+			return
+		}
+	}
+
+	if req.NewPassword != nil {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+			return
+		}
+		updates["password_hash"] = string(hashedPassword)
+	}
+
+	if newEmail != "" {
+		var taken int64
+		if err := h.db.Model(&models.User{}).Where("email = ? AND id <> ?", newEmail, user.ID).Count(&taken).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+		if taken > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+			return
+		}
+		updates["pending_email"] = newEmail
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(user).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if newEmail == "" {
+			return nil
+		}
+
+		// Only the latest requested address can be confirmed
+		if err := tx.Where("user_id = ? AND email <> ''", user.ID).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		verification := models.EmailVerification{
+			UserID:    user.ID,
+			Token:     uuid.New().String(),
+			Email:     newEmail,
+			ExpiresAt: time.Now().Add(24 * time.Hour),
+		}
+		if err := tx.Create(&verification).Error; err != nil {
+			return err
+		}
+		if err := h.email.QueueEmailChange(tx, user, &verification); err != nil {
+			return err
+		}
+		return h.email.QueueEmailNotice(tx, user, newEmail)
+	})
+	if err != nil {
+		log.Printf("Failed to update profile of %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.NewPassword != nil {
+		h.signOutOtherSessions(c, user.ID)
+	}
+	if _, ok := updates["avatar_key"]; ok && user.AvatarKey != "" {
+		h.deleteAvatar(c, user.AvatarKey)
+	}
+
+	if len(changed) > 0 {
+		h.bus.Publish(ctx, events.Event{
+			Type:    events.ProfileUpdated,
+			UserID:  user.ID,
+			Payload: events.ProfileUpdatedPayload{Fields: changed},
+		})
+	}
+
+	if err := h.db.First(user, "id = ?", user.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+	c.JSON(http.StatusOK, h.profileResponse(c, user))
+}
+
+// reauthWindow is how recently a passwordless account must have signed in
+// to change its email or delete itself without an MFA code
+const reauthWindow = 10 * time.Minute
+
+// reauthenticate makes the user prove who they are before a sensitive
+// change, so a stolen access token is not enough. Accounts with a password
+// confirm it. Passwordless accounts confirm an MFA code or must have signed
+// in within reauthWindow. It responds and reports false when the check
+// fails.
+func (h *UserHandler) reauthenticate(c *gin.Context, user *models.User, password, code string) bool {
+	if user.PasswordHash != "" {
+		return h.checkCurrentPassword(c, user, password)
+	}
+	if code != "" && mfa.Enabled(user) {
+		return h.checkMFACode(c, user, code)
+	}
+
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return false
+	}
+	session, err := h.tokens.Session(c.Request.Context(), user.ID, claims.SessionID)
+	if err != nil && !errors.Is(err, tokens.ErrSessionNotFound) {
+		log.Printf("⚠️ Failed to look up session %s: %v", claims.SessionID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify session"})
+		return false
+	}
+	if err != nil || time.Since(session.CreatedAt) > reauthWindow {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":           "Sign in again to confirm this change",
+			"reauth_required": true,
+			"mfa_enabled":     mfa.Enabled(user),
+		})
+		return false
+	}
+	return true
+}
+
+// checkMFACode verifies an MFA code of a passwordless account, throttling
+// guesses like the MFA settings do. It responds and reports false when the
+// code is wrong.
+func (h *UserHandler) checkMFACode(c *gin.Context, user *models.User, code string) bool {
+	attempt := lockout.Attempt{Account: user.Email, IP: c.ClientIP(), UserID: &user.ID}
+	if throttled(c, h.lockout, lockout.ScopeMFA, attempt) {
+		return false
+	}
+
+	err := h.mfa.Verify(c.Request.Context(), user, code)
+	if errors.Is(err, mfa.ErrInvalidCode) {
+		recordFailure(c, h.lockout, lockout.ScopeMFA, attempt)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return false
+	}
+	if err != nil {
+		log.Printf("Failed to verify MFA code of %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+		return false
+	}
+
+	if err := h.lockout.Succeed(c.Request.Context(), lockout.ScopeMFA, user.Email); err != nil {
+		log.Printf("⚠️ Failed to clear MFA failures for %s: %v", user.ID, err)
+	}
+	return true
+}
+
+// checkCurrentPassword verifies the current password, throttling guesses
+// like logins. It responds and reports false when the password is wrong.
+func (h *UserHandler) checkCurrentPassword(c *gin.Context, user *models.User, password string) bool {
+	attempt := lockout.Attempt{Account: user.Email, IP: c.ClientIP(), UserID: &user.ID}
+	if throttled(c, h.lockout, lockout.ScopeProfile, attempt) {
+		return false
+	}
+
+	if password == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		recordFailure(c, h.lockout, lockout.ScopeProfile, attempt)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return false
+	}
+
+	if err := h.lockout.Succeed(c.Request.Context(), lockout.ScopeProfile, user.Email); err != nil {
+		log.Printf("⚠️ Failed to clear profile password failures for %s: %v", user.ID, err)
+	}
+	return true
+}
+
+// signOutOtherSessions revokes every session except the one making the
+// request
+func (h *UserHandler) signOutOtherSessions(c *gin.Context, userID uuid.UUID) {
+	ctx := c.Request.Context()
+	var current uuid.UUID
+	if claims, ok := middleware.GetClaims(c); ok {
+		current = claims.SessionID
+	}
+
+	sessions, err := h.tokens.Sessions(ctx, userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to list sessions of %s: %v", userID, err)
+		return
+	}
+	for _, s := range sessions {
+		if s.ID == current {
+			continue
+		}
+		if err := h.tokens.RevokeSession(ctx, userID, s.ID); err != nil {
+			log.Printf("⚠️ Failed to revoke session %s: %v", s.ID, err)
+		}
+	}
+}
+
+func (h *UserHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return nil, false
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// profileResponse is the profile as the user sees it
+func (h *UserHandler) profileResponse(c *gin.Context, user *models.User) gin.H {
+	resp := gin.H{
+		"id":              user.ID,
+		"email":           user.Email,
+		"pending_email":   user.PendingEmail,
+		"first_name":      user.FirstName,
+		"last_name":       user.LastName,
+		"province":        user.Province,
+		"regulator":       nil,
+		"exam_date":       nil,
+		"timezone":        user.Timezone,
+		"avatar_url":      h.media.AvatarURL(c.Request.Context(), user),
+		"avatar_urls":     h.media.AvatarURLs(c.Request.Context(), user),
+		"is_verified":     user.IsVerified,
+		"has_password":    user.PasswordHash != "",
+		"oauth_provider":  user.OAuthProvider,
+		"mfa_enabled":     mfa.Enabled(user),
+		"study_streak":    user.StudyStreak,
+		"deletion_due_at": user.DeletionDueAt,
+		"created_at":      user.CreatedAt,
+	}
+	if regulator, ok := province.Lookup(user.Province); ok {
+		resp["regulator"] = regulator
+	}
+	if user.ExamDate != nil {
+		resp["exam_date"] = user.ExamDate.Format("2006-01-02")
+	}
+	return resp
+}
+
+// parseExamDate accepts a date or an RFC 3339 timestamp and keeps only the
+// date. The empty string clears the exam date.
+func parseExamDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		ts, tsErr := time.Parse(time.RFC3339, value)
+		if tsErr != nil {
+			return nil, errors.New("exam_date must be a date (YYYY-MM-DD)")
+		}
+		t = time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	// A day of slack so users west of UTC can pick their today
+	if t.Before(time.Now().UTC().AddDate(0, 0, -1)) {
+		return nil, errors.New("exam_date cannot be in the past")
+	}
+	return &t, nil
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Format("2006-01-02") == b.UTC().Format("2006-01-02")
+}
+
+// DeleteAccountRequest confirms a deletion. Accounts without a password,
+// such as Google sign-ins, send an MFA code or must have signed in
+// recently.
+type DeleteAccountRequest struct {
+	CurrentPassword string `json:"current_password"`
+	MFACode         string `json:"mfa_code"`
+}
+
+// DeleteAccount schedules the current user's account for deletion once
+// the grace period is over and signs out every other session. Until then
+// the user can still sign in and restore it.
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !h.reauthenticate(c, user, req.CurrentPassword, req.MFACode) {
+		return
+	}
+
+	dueAt, err := h.deletion.Schedule(c.Request.Context(), user, auditActor(c))
+	if errors.Is(err, deletion.ErrAlreadyScheduled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is already scheduled", "deletion_due_at": dueAt})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to schedule deletion of %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+
+	h.signOutOtherSessions(c, user.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         "Account scheduled for deletion",
+		"deletion_due_at": dueAt,
+	})
+}
+
+// RestoreAccount cancels a pending deletion of the current user's account
+func (h *UserHandler) RestoreAccount(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deletion.Cancel(c.Request.Context(), user, auditActor(c)); err != nil {
+		if errors.Is(err, deletion.ErrNotScheduled) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is not scheduled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.profileResponse(c, user))
+}
+
+// UploadAvatar replaces the current user's avatar with the image in the
+// multipart field "avatar". The image is re-encoded at every avatar size.
+func (h *UserHandler) UploadAvatar(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.Storage.AvatarMaxBytes+64<<10)
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Avatar is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An image is required in the avatar field"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+		return
+	}
+	defer f.Close()
+
+	ctx := c.Request.Context()
+	prefix, err := h.media.SaveAvatar(ctx, user.ID, f)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Avatar is too large"})
+		case errors.Is(err, media.ErrUnsupportedType):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Avatar must be a JPEG, PNG or GIF image"})
+		case errors.Is(err, media.ErrInvalidImage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar is not a valid image"})
+		default:
+			log.Printf("Failed to save avatar of %s: %v", user.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+		}
+		return
+	}
+
+	previous := user.AvatarKey
+	if err := h.db.Model(user).Update("avatar_key", prefix).Error; err != nil {
+		h.deleteAvatar(c, prefix)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+		return
+	}
+	user.AvatarKey = prefix
+	if previous != "" {
+		h.deleteAvatar(c, previous)
+	}
+
+	h.bus.Publish(ctx, events.Event{
+		Type:    events.ProfileUpdated,
+		UserID:  user.ID,
+		Payload: events.ProfileUpdatedPayload{Fields: []string{"avatar_url"}},
+	})
+
+	c.JSON(http.StatusOK, h.profileResponse(c, user))
+}
+
+// deleteAvatar removes a replaced avatar. Failures only leave orphaned
+// blobs behind, so they are logged rather than reported.
+func (h *UserHandler) deleteAvatar(c *gin.Context, prefix string) {
+	if err := h.media.DeleteAvatar(c.Request.Context(), prefix); err != nil {
+		log.Printf("⚠️ Failed to delete avatar %s: %v", prefix, err)
+	}
+}
+
+// GetBookmarks returns a page of the user's bookmarks, newest first, with
+// their notes and questions. folder_id narrows them to one folder, or to
+// those in no folder with "none".
+func (h *UserHandler) GetBookmarks(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req dto.ListBookmarksFilter
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter, err := req.Bookmarks()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	list, total, err := h.bookmarks.List(c.Request.Context(), userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+		return
+	}
+
+	items := make([]dto.BookmarkResponse, len(list))
+	for i := range list {
+		items[i] = buildBookmarkResponse(&list[i])
+	}
+
+	c.JSON(http.StatusOK, dto.ListBookmarksResponse{
+		Items:    items,
+		Total:    total,
+		Page:     req.GetPage(),
+		PageSize: req.GetPageSize(),
+	})
+}
+
+// UpdateBookmark moves a bookmark to another folder or edits its note
+func (h *UserHandler) UpdateBookmark(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	questionID, err := uuid.Parse(c.Param("question_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	var req dto.BookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	change, err := req.Change()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	bookmark, err := h.bookmarks.Update(c.Request.Context(), userID, questionID, change)
+	if err != nil {
+		switch {
+		case errors.Is(err, bookmarks.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bookmark not found"})
+		case errors.Is(err, bookmarks.ErrFolderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bookmark"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, buildBookmarkResponse(bookmark))
+}
+
+// GetBookmarkFolders returns the user's bookmark folders with the number
+// of bookmarks in each
+func (h *UserHandler) GetBookmarkFolders(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	folders, err := h.bookmarks.Folders(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch folders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"folders": folders})
+}
+
+// CreateBookmarkFolder adds a bookmark folder
+func (h *UserHandler) CreateBookmarkFolder(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req dto.BookmarkFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	folder, err := h.bookmarks.CreateFolder(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		bookmarkFolderError(c, err, "Failed to create folder")
+		return
+	}
+
+	c.JSON(http.StatusCreated, folder)
+}
+
+// RenameBookmarkFolder renames a bookmark folder
+func (h *UserHandler) RenameBookmarkFolder(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	var req dto.BookmarkFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	folder, err := h.bookmarks.RenameFolder(c.Request.Context(), userID, id, req.Name)
+	if err != nil {
+		bookmarkFolderError(c, err, "Failed to rename folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, folder)
+}
+
+// DeleteBookmarkFolder deletes a bookmark folder. The bookmarks in it are
+// kept, in no folder.
+func (h *UserHandler) DeleteBookmarkFolder(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid folder ID"})
+		return
+	}
+
+	if err := h.bookmarks.DeleteFolder(c.Request.Context(), userID, id); err != nil {
+		bookmarkFolderError(c, err, "Failed to delete folder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder deleted"})
+}
+
+// bookmarkFolderError responds to a failed folder change
+func bookmarkFolderError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, bookmarks.ErrFolderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+	case errors.Is(err, bookmarks.ErrFolderExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "A folder with this name already exists"})
+	case errors.Is(err, bookmarks.ErrTooManyFolders):
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("You can have at most %d folders", bookmarks.MaxFolders)})
+	case errors.Is(err, bookmarks.ErrInvalidName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder name is required"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetTestHistory returns user's practice test history
+func (h *UserHandler) GetTestHistory(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var tests []models.PracticeTest
+	if err := h.db.Where("user_id = ?", userID).
+		Order("started_at DESC").
+		Find(&tests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch test history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tests)
+}
+
+// GetStudyPath returns user's study path
+func (h *UserHandler) GetStudyPath(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Get study path endpoint"})
+}
+
+// UpdateModuleProgressRequest reports progress through a study module
+type UpdateModuleProgressRequest struct {
+	Progress         int `json:"progress" binding:"min=0,max=100"`
+	TimeSpentSeconds int `json:"time_spent_seconds" binding:"min=0"`
+}
+
+// UpdateModuleProgress records progress through a study module. Progress
+// never goes backwards; time spent is added to the running total.
+func (h *UserHandler) UpdateModuleProgress(c *gin.Context) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	moduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+		return
+	}
+
+	var req UpdateModuleProgressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var module models.Module
+	if err := h.db.First(&module, "id = ?", moduleID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Module not found"})
+		return
+	}
+
+	now := time.Now()
+	var progress models.UserModuleProgress
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND module_id = ?", userID, moduleID).First(&progress).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			progress = models.UserModuleProgress{UserID: userID, ModuleID: moduleID, StartedAt: &now}
+		} else if err != nil {
+			return err
+		}
+
+		if req.Progress > progress.Progress {
+			progress.Progress = req.Progress
+		}
+		progress.TimeSpent += req.TimeSpentSeconds
+		progress.Status = "in_progress"
+		if progress.Progress >= 100 {
+			progress.Status = "completed"
+			if progress.CompletedAt == nil {
+				progress.CompletedAt = &now
+			}
+		}
+		if err := tx.Save(&progress).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.UserStats{}).
+			Where("user_id = ?", userID).
+			Update("time_studied_seconds", gorm.Expr("time_studied_seconds + ?", req.TimeSpentSeconds)).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update module progress"})
+		return
+	}
+
+	h.bus.Publish(c.Request.Context(), events.Event{
+		Type:       events.ModuleProgressed,
+		UserID:     userID,
+		OccurredAt: now,
+		Payload: events.ModuleProgressedPayload{
+			ModuleID:  moduleID,
+			Progress:  progress.Progress,
+			Completed: progress.Status == "completed",
+		},
+	})
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// CreateSubscription creates a new subscription
+func (h *UserHandler) CreateSubscription(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Create subscription endpoint"})
+}
+
+// GetSubscription returns current subscription
+func (h *UserHandler) GetSubscription(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Get subscription endpoint"})
+}
+
+// CancelSubscription cancels the subscription
+func (h *UserHandler) CancelSubscription(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Cancel subscription endpoint"})
+}
+
+// GetNotifications returns user notifications
+func (h *UserHandler) GetNotifications(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Get notifications endpoint"})
+}
+
+// MarkNotificationRead marks a notification as read
+func (h *UserHandler) MarkNotificationRead(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Mark notification read endpoint"})
+}
+
+// UpdateNotificationSettings updates notification settings
+func (h *UserHandler) UpdateNotificationSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "Update notification settings endpoint"})
+}
+
+// ListUsers returns all users (admin only)
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": "List users endpoint (admin)"})
+}
+
+// StripeWebhook handles Stripe webhook events
+func (h *UserHandler) StripeWebhook(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
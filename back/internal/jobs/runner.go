@@ -0,0 +1,73 @@
+// Package jobs runs periodic background work alongside the API server.
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nppe-pro/api/pkg/database"
+)
+
+// Job is a unit of periodic work
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner executes jobs on their interval. When several API replicas share a
+// Redis instance, a lock ensures each tick runs on only one of them.
+type Runner struct {
+	redis *database.RedisClient
+}
+
+// NewRunner creates a job runner
+func NewRunner(redis *database.RedisClient) *Runner {
+	return &Runner{redis: redis}
+}
+
+// Start runs job every interval until ctx is cancelled. It blocks, so call
+// it in its own goroutine.
+func (r *Runner) Start(ctx context.Context, job Job) {
+	if job.Interval <= 0 {
+		log.Printf("⚠️ Job %s disabled: interval must be positive", job.Name)
+		return
+	}
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.tick(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) tick(ctx context.Context, job Job) {
+	if r.redis != nil {
+		// The lock expires just before the next tick so a crashed replica
+		// never blocks the job for longer than one interval
+		ttl := job.Interval - time.Second
+		if ttl <= 0 {
+			ttl = job.Interval
+		}
+		acquired, err := r.redis.SetNX(ctx, "job_lock:"+job.Name, time.Now().Unix(), ttl)
+		if err != nil {
+			log.Printf("⚠️ Job %s: failed to acquire lock: %v", job.Name, err)
+			return
+		}
+		if !acquired {
+			return
+		}
+	}
+
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("⚠️ Job %s failed: %v", job.Name, err)
+	}
+}
@@ -0,0 +1,254 @@
+// Package lockout slows down and then locks out clients that keep failing
+// authentication.
+//
+// Failures are counted in Redis per account and per IP for each Scope.
+// After FreeAttempts failures every further one imposes an exponentially
+// growing wait, and reaching the threshold locks the account or IP for the
+// lockout duration. Locks and unlocks are written to the audit log, which
+// keeps them forever, so emails and IPs appear there only as pseudonyms.
+package lockout
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/audit"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Scope separates the counters of different endpoints
+type Scope string
+
+const (
+	ScopeLogin          Scope = "login"
+	ScopeForgotPassword Scope = "forgot_password"
+	ScopeResetPassword  Scope = "reset_password"
+	ScopeProfile        Scope = "profile" // current password when changing email or password
+	ScopeMFA            Scope = "mfa"     // second-factor codes: login challenges, managing MFA, confirming a change
+)
+
+// Attempt identifies who is trying. Account is the email address tried,
+// UserID is set when it belongs to a known user.
+type Attempt struct {
+	Account string
+	IP      string
+	UserID  *uuid.UUID
+}
+
+// Guard tracks failures and decides when to refuse attempts
+type Guard struct {
+	db       *gorm.DB
+	redis    *database.RedisClient
+	config   *config.Config
+	auditKey []byte
+}
+
+// NewGuard creates a new guard
+func NewGuard(db *gorm.DB, redis *database.RedisClient, cfg *config.Config) *Guard {
+	return &Guard{db: db, redis: redis, config: cfg, auditKey: AuditKey(cfg)}
+}
+
+// AuditKey returns the key of the pseudonyms in lock events
+func AuditKey(cfg *config.Config) []byte {
+	if cfg.Lockout.AuditKey != "" {
+		return []byte(cfg.Lockout.AuditKey)
+	}
+	return []byte(cfg.JWT.Secret)
+}
+
+// AccountPseudonym returns the pseudonym an email address is audited under
+func AccountPseudonym(cfg *config.Config, account string) string {
+	return audit.Pseudonym(AuditKey(cfg), normalizeAccount(account))
+}
+
+// subject is one counter: an account or an IP within a scope
+type subject struct {
+	scope     Scope
+	kind      string // account or ip
+	id        string
+	threshold int
+}
+
+func (s subject) key(prefix string) string {
+	return prefix + ":" + string(s.scope) + ":" + s.kind + ":" + s.id
+}
+
+func (g *Guard) subjects(scope Scope, attempt Attempt) []subject {
+	var subjects []subject
+	if account := normalizeAccount(attempt.Account); account != "" {
+		subjects = append(subjects, subject{scope, "account", account, g.config.Lockout.AccountThreshold})
+	}
+	if attempt.IP != "" {
+		subjects = append(subjects, subject{scope, "ip", attempt.IP, g.config.Lockout.IPThreshold})
+	}
+	return subjects
+}
+
+// Check returns how long attempt must wait before it is allowed, or zero
+func (g *Guard) Check(ctx context.Context, scope Scope, attempt Attempt) (time.Duration, error) {
+	subjects := g.subjects(scope, attempt)
+
+	pipe := g.redis.Client.Pipeline()
+	cmds := make([]*redis.DurationCmd, 0, 2*len(subjects))
+	for _, s := range subjects {
+		cmds = append(cmds, pipe.PTTL(ctx, s.key("auth_lockout")), pipe.PTTL(ctx, s.key("auth_backoff")))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	for _, cmd := range cmds {
+		if ttl := cmd.Val(); ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait, nil
+}
+
+// Fail records a failed attempt and returns how long the client must now
+// wait
+func (g *Guard) Fail(ctx context.Context, scope Scope, attempt Attempt) (time.Duration, error) {
+	var wait time.Duration
+	for _, s := range g.subjects(scope, attempt) {
+		w, err := g.fail(ctx, s, attempt)
+		if err != nil {
+			return 0, err
+		}
+		if w > wait {
+			wait = w
+		}
+	}
+	return wait, nil
+}
+
+func (g *Guard) fail(ctx context.Context, s subject, attempt Attempt) (time.Duration, error) {
+	cfg := g.config.Lockout
+
+	failures, err := g.redis.Increment(ctx, s.key("auth_failures"))
+	if err != nil {
+		return 0, err
+	}
+	if failures == 1 {
+		if err := g.redis.Expire(ctx, s.key("auth_failures"), cfg.Window); err != nil {
+			return 0, err
+		}
+	}
+
+	if failures >= int64(s.threshold) {
+		locked, err := g.redis.SetNX(ctx, s.key("auth_lockout"), failures, cfg.Duration)
+		if err != nil {
+			return 0, err
+		}
+		if locked {
+			action := models.AuditAccountLocked
+			if s.kind == "ip" {
+				action = models.AuditIPLocked
+			}
+			g.audit(ctx, action, s, attempt.UserID, attempt.UserID, map[string]interface{}{
+				"scope":        s.scope,
+				"failures":     failures,
+				"locked_until": time.Now().Add(cfg.Duration).UTC(),
+			})
+		}
+		return cfg.Duration, nil
+	}
+
+	if failures <= int64(cfg.FreeAttempts) {
+		return 0, nil
+	}
+
+	wait := backoff(cfg.BackoffBase, cfg.BackoffMax, int(failures)-cfg.FreeAttempts)
+	if err := g.redis.Set(ctx, s.key("auth_backoff"), failures, wait); err != nil {
+		return 0, err
+	}
+	return wait, nil
+}
+
+// Succeed clears the account's failures after a successful attempt. IP
+// failures are kept so that one valid login does not reset a spray.
+func (g *Guard) Succeed(ctx context.Context, scope Scope, account string) error {
+	account = normalizeAccount(account)
+	if account == "" {
+		return nil
+	}
+	s := subject{scope: scope, kind: "account", id: account}
+	return g.redis.Delete(ctx, s.key("auth_failures"), s.key("auth_backoff"))
+}
+
+// Unlock lifts the login and MFA lockouts on user's account, for example
+// after the password was reset, and audits each lock it removed. actorID
+// is who unlocked it.
+func (g *Guard) Unlock(ctx context.Context, user *models.User, actorID *uuid.UUID, reason string) error {
+	account := normalizeAccount(user.Email)
+	if account == "" {
+		return nil
+	}
+
+	for _, scope := range []Scope{ScopeLogin, ScopeMFA} {
+		s := subject{scope: scope, kind: "account", id: account}
+		removed, err := g.redis.Client.Del(ctx, s.key("auth_lockout")).Result()
+		if err != nil {
+			return err
+		}
+		if err := g.redis.Delete(ctx, s.key("auth_failures"), s.key("auth_backoff")); err != nil {
+			return err
+		}
+
+		if removed > 0 {
+			userID := user.ID
+			g.audit(ctx, models.AuditAccountUnlocked, s, actorID, &userID, map[string]interface{}{
+				"reason": reason,
+				"scope":  scope,
+			})
+		}
+	}
+	return nil
+}
+
+// audit records a lock event. A known account is recorded by user ID; an
+// unknown email or an IP only by its pseudonym, and the client IP is left
+// out. A failure to audit must not turn into a failed login, so it is only
+// logged.
+func (g *Guard) audit(ctx context.Context, action string, s subject, actorID, userID *uuid.UUID, details map[string]interface{}) {
+	entry := audit.Entry{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: s.kind,
+		EntityID:   audit.Pseudonym(g.auditKey, s.id),
+		Details:    details,
+	}
+	if s.kind == "account" && userID != nil {
+		entry.EntityType = "user"
+		entry.EntityID = userID.String()
+	}
+
+	if err := audit.Record(g.db.WithContext(ctx), entry); err != nil {
+		log.Printf("⚠️ Failed to audit %s for %s %s: %v", action, entry.EntityType, entry.EntityID, err)
+	}
+	log.Printf("🔒 %s: %s %s (%s)", action, entry.EntityType, entry.EntityID, s.scope)
+}
+
+// backoff returns the wait after the given number of failures past the
+// free attempts
+func backoff(base, max time.Duration, n int) time.Duration {
+	wait := base
+	for i := 1; i < n && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,52 @@
+// Package mastery maintains a recency-weighted estimate of how well a user
+// knows a topic.
+//
+// The estimate is an exponentially weighted moving average of the credit
+// earned on each attempt. Early on the weight of a new attempt is 1/n, so
+// the first answers are averaged evenly; once n exceeds 1/MinWeight every
+// new attempt keeps contributing MinWeight, letting recent practice
+// outweigh answers from weeks ago.
+package mastery
+
+import (
+	"time"
+
+	"github.com/nppe-pro/api/internal/models"
+)
+
+// MinWeight is the smallest weight given to a new attempt
+const MinWeight = 0.1
+
+// Record folds one attempt into m. credit is between 0 and 1.
+func Record(m *models.UserTopicMastery, correct bool, credit float64, at time.Time) {
+	m.QuestionsAttempted++
+	if correct {
+		m.QuestionsCorrect++
+	}
+
+	weight := 1 / float64(m.QuestionsAttempted)
+	if weight < MinWeight {
+		weight = MinWeight
+	}
+
+	m.MasteryPercentage += weight * (credit*100 - m.MasteryPercentage)
+	if m.MasteryPercentage < 0 {
+		m.MasteryPercentage = 0
+	} else if m.MasteryPercentage > 100 {
+		m.MasteryPercentage = 100
+	}
+
+	m.LastPracticed = at
+}
+
+// A topic counts as mastered once the estimate reaches MasteredPercentage
+// over at least MasteredMinAttempts answers
+const (
+	MasteredPercentage  = 80.0
+	MasteredMinAttempts = 10
+)
+
+// Mastered reports whether m meets the mastered threshold
+func Mastered(m *models.UserTopicMastery) bool {
+	return m.QuestionsAttempted >= MasteredMinAttempts && m.MasteryPercentage >= MasteredPercentage
+}
@@ -0,0 +1,197 @@
+// Package media validates, re-encodes and stores uploads, for now avatars.
+// Content types are sniffed from the bytes, never taken from the client, and
+// images are decoded and re-encoded so nothing but pixels reaches storage.
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/storage"
+)
+
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrInvalidImage    = errors.New("file is not a valid image")
+)
+
+// AvatarSizes are the square edge lengths every avatar is stored at
+var AvatarSizes = []int{64, 128, 256}
+
+// DefaultAvatarSize is the size returned as avatar_url
+const DefaultAvatarSize = 128
+
+const (
+	maxPixels   = 12_000_000 // refuse images that would decode to more than this, about 48 MB of RGBA
+	jpegQuality = 85
+)
+
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Service stores uploads and signs links to them
+type Service struct {
+	store  storage.Store
+	config *config.Config
+}
+
+// NewService creates a new media service
+func NewService(store storage.Store, cfg *config.Config) *Service {
+	return &Service{store: store, config: cfg}
+}
+
+// SaveAvatar stores a square JPEG of the image at every AvatarSizes size
+// and returns the key prefix to keep on the user
+func (s *Service) SaveAvatar(ctx context.Context, userID uuid.UUID, r io.Reader) (string, error) {
+	data, err := readLimited(r, s.config.Storage.AvatarMaxBytes)
+	if err != nil {
+		return "", err
+	}
+	img, _, err := decodeImage(data)
+	if err != nil {
+		return "", err
+	}
+
+	// Flatten onto white: JPEG has no transparency
+	square := flatten(img, centerSquare(img.Bounds()), image.NewUniform(color.White))
+
+	prefix := "avatars/" + userID.String() + "/" + randomID()
+	for _, size := range AvatarSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size, size), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return "", err
+		}
+		if err := s.store.Put(ctx, avatarKey(prefix, size), &buf, int64(buf.Len()), "image/jpeg"); err != nil {
+			s.DeleteAvatar(ctx, prefix)
+			return "", fmt.Errorf("failed to store avatar: %w", err)
+		}
+	}
+	return prefix, nil
+}
+
+// DeleteAvatar removes every size of an avatar
+func (s *Service) DeleteAvatar(ctx context.Context, prefix string) error {
+	var firstErr error
+	for _, size := range AvatarSizes {
+		if err := s.store.Delete(ctx, avatarKey(prefix, size)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// AvatarURLs returns signed links to each size of the user's uploaded
+// avatar, keyed by size. Users without one fall back to the picture of
+// their Google account, if any, at every size.
+func (s *Service) AvatarURLs(ctx context.Context, user *models.User) map[string]string {
+	urls := make(map[string]string, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		u := user.AvatarURL
+		if user.AvatarKey != "" {
+			signed, err := s.URL(ctx, avatarKey(user.AvatarKey, size))
+			if err != nil {
+				return nil
+			}
+			u = signed
+		}
+		if u == "" {
+			return nil
+		}
+		urls[strconv.Itoa(size)] = u
+	}
+	return urls
+}
+
+// AvatarURL returns the link to the user's avatar at DefaultAvatarSize, or
+// "" when they have none
+func (s *Service) AvatarURL(ctx context.Context, user *models.User) string {
+	return s.AvatarURLs(ctx, user)[strconv.Itoa(DefaultAvatarSize)]
+}
+
+// URL signs a link to a stored key, valid for STORAGE_URL_EXPIRY
+func (s *Service) URL(ctx context.Context, key string) (string, error) {
+	return s.store.SignedURL(ctx, key, s.config.Storage.URLExpiry)
+}
+
+// decodeImage checks the sniffed type and dimensions before decoding, so a
+// small file cannot expand into a huge bitmap
+func decodeImage(data []byte) (*image.RGBA, string, error) {
+	contentType := sniff(data)
+	if !imageTypes[contentType] {
+		return nil, "", ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrInvalidImage
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", ErrInvalidImage
+	}
+
+	rgba := flatten(img, img.Bounds(), nil)
+	if contentType == "image/jpeg" {
+		rgba = orient(rgba, jpegOrientation(data))
+	}
+	return rgba, contentType, nil
+}
+
+func sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// readLimited reads all of r, failing with ErrTooLarge past limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func avatarKey(prefix string, size int) string {
+	return prefix + "/" + strconv.Itoa(size) + ".jpg"
+}
+
+// randomID names each upload uniquely, so a new upload never reuses a URL
+// a browser may have cached
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
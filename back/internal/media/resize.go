@@ -0,0 +1,109 @@
+package media
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// flatten draws the part r of src onto an opaque RGBA canvas, filling
+// transparent areas with bg
+func flatten(src image.Image, r image.Rectangle, bg image.Image) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	if bg != nil {
+		draw.Draw(dst, dst.Bounds(), bg, image.Point{}, draw.Src)
+		draw.Draw(dst, dst.Bounds(), src, r.Min, draw.Over)
+	} else {
+		draw.Draw(dst, dst.Bounds(), src, r.Min, draw.Src)
+	}
+	return dst
+}
+
+// centerSquare returns the largest centred square inside b
+func centerSquare(b image.Rectangle) image.Rectangle {
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// contribution lists the source pixels that make up one destination pixel
+// and their weights
+type contribution struct {
+	start   int
+	weights []float32
+}
+
+// contributions maps dst pixels onto src pixels by area coverage, which
+// averages every source pixel when shrinking
+func contributions(dst, src int) []contribution {
+	scale := float64(src) / float64(dst)
+	out := make([]contribution, dst)
+	for i := range out {
+		lo := float64(i) * scale
+		hi := lo + scale
+		start := int(lo)
+		end := min(int(math.Ceil(hi)), src)
+
+		weights := make([]float32, end-start)
+		var sum float64
+		for j := start; j < end; j++ {
+			w := math.Min(hi, float64(j+1)) - math.Max(lo, float64(j))
+			weights[j-start] = float32(w)
+			sum += w
+		}
+		for k := range weights {
+			weights[k] /= float32(sum)
+		}
+		out[i] = contribution{start: start, weights: weights}
+	}
+	return out
+}
+
+// resize scales src to w x h with an area-averaging filter, one axis at a
+// time
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw == w && sh == h {
+		return src
+	}
+
+	// Horizontal pass into a float buffer of w x sh
+	cols := contributions(w, sw)
+	tmp := make([]float32, w*sh*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, c := range cols {
+			var r, g, b, a float32
+			for k, wt := range c.weights {
+				p := (c.start + k) * 4
+				r += float32(row[p]) * wt
+				g += float32(row[p+1]) * wt
+				b += float32(row[p+2]) * wt
+				a += float32(row[p+3]) * wt
+			}
+			o := (y*w + x) * 4
+			tmp[o], tmp[o+1], tmp[o+2], tmp[o+3] = r, g, b, a
+		}
+	}
+
+	// Vertical pass into the result
+	rows := contributions(h, sh)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y, c := range rows {
+		for x := 0; x < w; x++ {
+			var px [4]float32
+			for k, wt := range c.weights {
+				o := ((c.start+k)*w + x) * 4
+				for ch := 0; ch < 4; ch++ {
+					px[ch] += tmp[o+ch] * wt
+				}
+			}
+			o := y*dst.Stride + x*4
+			for ch := 0; ch < 4; ch++ {
+				dst.Pix[o+ch] = uint8(math.Min(255, math.Max(0, math.Round(float64(px[ch])))))
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,103 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrChallengeExpired is returned for an unknown, expired or exhausted
+// challenge token
+var ErrChallengeExpired = errors.New("mfa challenge expired")
+
+// Challenge is a password login waiting for its second factor
+type Challenge struct {
+	Token  string
+	UserID uuid.UUID
+	// Enroll is set when the user has no second factor yet but policy
+	// requires one; the challenge is then completed by enrolling
+	Enroll bool
+}
+
+func challengeKey(token string) string {
+	return "mfa_challenge:" + token
+}
+
+// NewChallenge starts a challenge for user after their password checked out
+func (s *Service) NewChallenge(ctx context.Context, user *models.User) (*Challenge, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	challenge := &Challenge{
+		Token:  base64.RawURLEncoding.EncodeToString(b),
+		UserID: user.ID,
+		Enroll: !Enabled(user),
+	}
+
+	key := challengeKey(challenge.Token)
+	pipe := s.redis.Client.TxPipeline()
+	pipe.HSet(ctx, key,
+		"user_id", challenge.UserID.String(),
+		"enroll", strconv.FormatBool(challenge.Enroll),
+		"attempts", 0,
+	)
+	pipe.Expire(ctx, key, s.config.MFA.ChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// Challenge looks up a pending challenge
+func (s *Service) Challenge(ctx context.Context, token string) (*Challenge, error) {
+	if token == "" {
+		return nil, ErrChallengeExpired
+	}
+
+	fields, err := s.redis.Client.HGetAll(ctx, challengeKey(token)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(fields["user_id"])
+	if err != nil {
+		return nil, ErrChallengeExpired
+	}
+	return &Challenge{
+		Token:  token,
+		UserID: userID,
+		Enroll: fields["enroll"] == "true",
+	}, nil
+}
+
+// failScript counts a failed attempt on an existing challenge and deletes
+// the challenge once ARGV[1] attempts have failed
+var failScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if attempts >= tonumber(ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+end
+return attempts
+`)
+
+// FailChallenge counts a wrong code against the challenge and ends it once
+// the attempts run out
+func (s *Service) FailChallenge(ctx context.Context, challenge *Challenge) error {
+	return failScript.Run(ctx, s.redis.Client, []string{challengeKey(challenge.Token)}, s.config.MFA.ChallengeAttempts).Err()
+}
+
+// EndChallenge removes a completed challenge
+func (s *Service) EndChallenge(ctx context.Context, challenge *Challenge) error {
+	return s.redis.Delete(ctx, challengeKey(challenge.Token))
+}
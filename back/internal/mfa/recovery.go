@@ -0,0 +1,58 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// recoveryAlphabet leaves out characters that are easy to misread
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// recoveryLength is the number of characters in a recovery code, shown as
+// two groups of five
+const recoveryLength = 10
+
+// GenerateRecoveryCodes returns n random recovery codes like "k7m2q-x9hbt"
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	// Bytes at or above limit are rejected so every character is equally likely
+	limit := byte(256 / len(recoveryAlphabet) * len(recoveryAlphabet))
+
+	codes := make([]string, n)
+	buf := make([]byte, recoveryLength)
+	for i := range codes {
+		var b strings.Builder
+		for chars := 0; chars < recoveryLength; {
+			if _, err := rand.Read(buf); err != nil {
+				return nil, err
+			}
+			for _, v := range buf {
+				if v >= limit || chars == recoveryLength {
+					continue
+				}
+				if chars == recoveryLength/2 {
+					b.WriteByte('-')
+				}
+				b.WriteByte(recoveryAlphabet[int(v)%len(recoveryAlphabet)])
+				chars++
+			}
+		}
+		codes[i] = b.String()
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode returns the stored form of a recovery code. Codes are
+// compared case-insensitively and without separators.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
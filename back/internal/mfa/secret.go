@@ -0,0 +1,96 @@
+package mfa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// sealedPrefix marks an encrypted secret. Secrets stored before encryption
+// are plain base32, which never contains a colon.
+const sealedPrefix = "v1:"
+
+var errSealedSecret = errors.New("mfa secret cannot be decrypted")
+
+// secretKey returns the AES-256 key of TOTP secrets at rest, derived from
+// MFA_ENCRYPTION_KEY or, in development, JWT_SECRET
+func secretKey(cfg *config.Config) cipher.AEAD {
+	key := cfg.MFA.EncryptionKey
+	if key == "" {
+		key = cfg.JWT.Secret
+	}
+	sum := sha256.Sum256([]byte(key))
+	// Neither call can fail for a 32 byte AES key
+	block, _ := aes.NewCipher(sum[:])
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+// sealSecret encrypts a TOTP secret for userID. The user ID is bound as
+// additional data, so a secret copied onto another account does not open.
+func sealSecret(aead cipher.AEAD, userID uuid.UUID, secret string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), userID[:])
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openSecret returns the plain TOTP secret of a stored value. Values
+// written before encryption are returned as they are.
+func openSecret(aead cipher.AEAD, userID uuid.UUID, stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, sealedPrefix)
+	if !ok {
+		return stored, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errSealedSecret
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, userID[:])
+	if err != nil {
+		return "", errSealedSecret
+	}
+	return string(secret), nil
+}
+
+// SealSecrets encrypts the TOTP secrets stored before encryption. It runs
+// with the migrations and is a no-op once every secret is sealed.
+func SealSecrets(db *gorm.DB, cfg *config.Config) error {
+	var users []models.User
+	if err := db.Select("id", "mfa_secret").
+		Where("mfa_secret <> '' AND mfa_secret NOT LIKE ?", sealedPrefix+"%").
+		Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to find plain mfa secrets: %w", err)
+	}
+
+	aead := secretKey(cfg)
+	for _, user := range users {
+		sealed, err := sealSecret(aead, user.ID, user.MFASecret)
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&models.User{}).
+			Where("id = ? AND mfa_secret = ?", user.ID, user.MFASecret).
+			Update("mfa_secret", sealed).Error; err != nil {
+			return fmt.Errorf("failed to seal mfa secret of %s: %w", user.ID, err)
+		}
+	}
+	if len(users) > 0 {
+		log.Printf("🔐 Encrypted %d MFA secrets", len(users))
+	}
+	return nil
+}
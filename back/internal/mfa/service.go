@@ -0,0 +1,246 @@
+package mfa
+
+import (
+	"context"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/internal/rbac"
+	"github.com/nppe-pro/api/pkg/database"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCode is returned for a wrong, expired or reused code
+	ErrInvalidCode = errors.New("invalid mfa code")
+	// ErrAlreadyEnabled is returned when enrolling a user who has MFA on
+	ErrAlreadyEnabled = errors.New("mfa already enabled")
+	// ErrNotEnrolled is returned when there is no secret to check a code
+	// against
+	ErrNotEnrolled = errors.New("mfa not enrolled")
+	// ErrRequired is returned when disabling MFA the policy requires
+	ErrRequired = errors.New("mfa required for this account")
+)
+
+// Service manages enrolment and verification of second factors
+type Service struct {
+	db      *gorm.DB
+	redis   *database.RedisClient
+	rbac    *rbac.Service
+	secrets cipher.AEAD
+	config  *config.Config
+}
+
+// NewService creates a new MFA service
+func NewService(db *gorm.DB, redis *database.RedisClient, cfg *config.Config) *Service {
+	return &Service{db: db, redis: redis, rbac: rbac.NewService(db), secrets: secretKey(cfg), config: cfg}
+}
+
+// Enabled reports whether user has an active second factor
+func Enabled(user *models.User) bool {
+	return user.MFAEnabledAt != nil
+}
+
+// Required reports whether policy requires user to use MFA. Any account
+// holding a role counts as an admin.
+func (s *Service) Required(ctx context.Context, user *models.User) (bool, error) {
+	if !s.config.MFA.RequireForAdmins {
+		return false, nil
+	}
+	return s.rbac.IsStaff(ctx, user.ID)
+}
+
+// Needed reports whether user must pass a second factor to log in, either
+// because they enrolled or because policy requires them to
+func (s *Service) Needed(ctx context.Context, user *models.User) (bool, error) {
+	if Enabled(user) {
+		return true, nil
+	}
+	return s.Required(ctx, user)
+}
+
+// Enroll generates a new pending secret for user and returns it with its
+// provisioning URI. Any earlier pending secret is replaced.
+func (s *Service) Enroll(ctx context.Context, user *models.User) (string, string, error) {
+	if Enabled(user) {
+		return "", "", ErrAlreadyEnabled
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := sealSecret(s.secrets, user.ID, secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.db.WithContext(ctx).Model(user).Updates(map[string]interface{}{
+		"mfa_secret":    sealed,
+		"mfa_last_step": 0,
+	}).Error; err != nil {
+		return "", "", fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+
+	return secret, ProvisioningURI(s.config.MFA.Issuer, user.Email, secret), nil
+}
+
+// Activate confirms a pending enrolment with a code from the authenticator
+// and returns the user's recovery codes
+func (s *Service) Activate(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if Enabled(user) {
+		return nil, ErrAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrNotEnrolled
+	}
+
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.useTOTP(tx, user, code); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(user).Update("mfa_enabled_at", now).Error; err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP or recovery code for a user with MFA enabled. Each
+// code is accepted once.
+func (s *Service) Verify(ctx context.Context, user *models.User, code string) error {
+	if !Enabled(user) {
+		return ErrNotEnrolled
+	}
+
+	db := s.db.WithContext(ctx)
+	if looksLikeTOTP(code) {
+		return s.useTOTP(db, user, code)
+	}
+	return s.useRecoveryCode(db, user.ID, code)
+}
+
+// Disable removes user's second factor after checking a current code
+func (s *Service) Disable(ctx context.Context, user *models.User, code string) error {
+	required, err := s.Required(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrRequired
+	}
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"mfa_secret":     "",
+			"mfa_enabled_at": nil,
+			"mfa_last_step":  0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes replaces user's recovery codes after checking a
+// current code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if err := s.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// RecoveryCodesLeft returns the number of unused recovery codes
+func (s *Service) RecoveryCodesLeft(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// useTOTP accepts a TOTP code and records its time step. The conditional
+// update makes concurrent logins with the same code race safely.
+func (s *Service) useTOTP(db *gorm.DB, user *models.User, code string) error {
+	secret, err := openSecret(s.secrets, user.ID, user.MFASecret)
+	if err != nil {
+		return err
+	}
+	step, ok := Match(secret, code, time.Now(), user.MFALastStep)
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	result := db.Model(&models.User{}).
+		Where("id = ? AND mfa_last_step < ?", user.ID, step).
+		Update("mfa_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	user.MFALastStep = step
+	return nil
+}
+
+// useRecoveryCode marks a recovery code as used
+func (s *Service) useRecoveryCode(db *gorm.DB, userID uuid.UUID, code string) error {
+	result := db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes deletes user's recovery codes and stores new ones
+func (s *Service) replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	codes, err := GenerateRecoveryCodes(s.config.MFA.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.MFARecoveryCode, len(codes))
+	for i, code := range codes {
+		rows[i] = models.MFARecoveryCode{UserID: userID, CodeHash: HashRecoveryCode(code)}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
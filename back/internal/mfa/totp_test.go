@@ -0,0 +1,161 @@
+package mfa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+)
+
+// rfcSecret is the SHA1 seed of the RFC 6238 test vectors, "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	code := func(step int64) string {
+		c, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		after    int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(current), 0, current, true},
+		{"previous step", code(current - 1), 0, current - 1, true},
+		{"next step", code(current + 1), 0, current + 1, true},
+		{"outside the skew", code(current - 2), 0, 0, false},
+		{"surrounding spaces", " " + code(current) + " ", 0, current, true},
+		{"already used", code(current), current, 0, false},
+		{"used earlier step", code(current), current - 1, current, true},
+		{"wrong code", "000000", 0, 0, false},
+		{"too short", code(current)[:5], 0, 0, false},
+		{"empty", "", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Match(rfcSecret, tt.code, now, tt.after)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Match = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLooksLikeTOTP(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"123456", true},
+		{" 123456 ", true},
+		{"12345", false},
+		{"1234567", false},
+		{"12a456", false},
+		{"k7m2q-x9hbt", false},
+	}
+	for _, tt := range tests {
+		if got := looksLikeTOTP(tt.code); got != tt.want {
+			t.Errorf("looksLikeTOTP(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := HashRecoveryCode("k7m2q-x9hbt")
+	for _, code := range []string{"k7m2qx9hbt", "K7M2Q-X9HBT", " k7m2q x9hbt "} {
+		if got := HashRecoveryCode(code); got != want {
+			t.Errorf("HashRecoveryCode(%q) differs from the canonical form", code)
+		}
+	}
+	if HashRecoveryCode("k7m2q-x9hbu") == want {
+		t.Error("different codes hash the same")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != recoveryLength+1 || code[recoveryLength/2] != '-' {
+			t.Errorf("malformed recovery code %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate recovery code %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestSealSecret(t *testing.T) {
+	aead := secretKey(&config.Config{MFA: config.MFAConfig{EncryptionKey: "test key"}})
+	owner, other := uuid.New(), uuid.New()
+
+	sealed, err := sealSecret(aead, owner, rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		user    uuid.UUID
+		stored  string
+		want    string
+		wantErr bool
+	}{
+		{"sealed", "test key", owner, sealed, rfcSecret, false},
+		{"plain legacy secret", "test key", owner, rfcSecret, rfcSecret, false},
+		{"other user", "test key", other, sealed, "", true},
+		{"other key", "other key", owner, sealed, "", true},
+		{"truncated", "test key", owner, sealed[:len(sealedPrefix)+4], "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aead := secretKey(&config.Config{MFA: config.MFAConfig{EncryptionKey: tt.key}})
+			got, err := openSecret(aead, tt.user, tt.stored)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("openSecret = (%q, %v), want (%q, error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Award kinds stored in UserAchievement.Kind
+const (
+	AwardKindAchievement = "achievement"
+	AwardKindBadge       = "badge"
+)
+
+// Achievement defines a one-off accomplishment. It is earned when Metric
+// reaches Threshold.
+type Achievement struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code        string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	Category    string    `gorm:"type:varchar(30)" json:"category"` // study, practice, mastery
+	Icon        string    `json:"icon"`
+	Rarity      string    `gorm:"type:varchar(20);default:'common'" json:"rarity"` // common, uncommon, rare, epic, legendary
+	Points      int       `gorm:"default:0" json:"points"`
+	Metric      string    `gorm:"type:varchar(50);not null" json:"metric"`
+	Threshold   float64   `gorm:"not null" json:"threshold"`
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Badge defines one level of a tiered award. Badges sharing a Family are
+// levels of the same badge.
+type Badge struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Code        string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"code"`
+	Family      string    `gorm:"type:varchar(50);index;not null" json:"family"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	Category    string    `gorm:"type:varchar(30)" json:"category"`
+	Icon        string    `json:"icon"`
+	Level       int       `gorm:"not null" json:"level"`
+	Metric      string    `gorm:"type:varchar(50);not null" json:"metric"`
+	Threshold   float64   `gorm:"not null" json:"threshold"`
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserAchievement records that a user earned an achievement or a badge.
+// The unique index guarantees each is awarded at most once.
+type UserAchievement struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID  `gorm:"index:idx_user_award,unique;not null" json:"user_id"`
+	Kind         string     `gorm:"type:varchar(20);index:idx_user_award,unique;not null" json:"kind"` // achievement, badge
+	DefinitionID uuid.UUID  `gorm:"type:uuid;index:idx_user_award,unique;not null" json:"definition_id"`
+	SourceType   string     `gorm:"type:varchar(30)" json:"source_type,omitempty"` // practice_test, question, topic, streak
+	SourceID     *uuid.UUID `gorm:"type:uuid;index" json:"source_id,omitempty"`
+	EarnedAt     time.Time  `gorm:"not null" json:"earned_at"`
+}
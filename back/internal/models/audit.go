@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions
+const (
+	AuditAccountLocked   = "auth.account_locked"
+	AuditAccountUnlocked = "auth.account_unlocked"
+	AuditIPLocked        = "auth.ip_locked"
+	AuditRolesChanged    = "user.roles_changed"
+	AuditQuestionCreated = "question.created"
+	AuditQuestionUpdated = "question.updated"
+	AuditQuestionDeleted = "question.deleted"
+	AuditDeletionRequest = "user.deletion_requested"
+	AuditDeletionCancel  = "user.deletion_cancelled"
+	AuditAccountPurged   = "user.purged"
+)
+
+// AuditLog is an append-only record of a security-relevant action. Rows are
+// never deleted, and only updated by an account purge to blank personal
+// data; a database trigger rejects anything else. Before and After hold only
+// the fields that changed.
+type AuditLog struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id,omitempty"` // nil for the system or an anonymous client
+	Action     string     `gorm:"type:varchar(64);index;not null" json:"action"`
+	EntityType string     `gorm:"type:varchar(32);index:idx_audit_entity" json:"entity_type"`
+	EntityID   string     `gorm:"type:varchar(255);index:idx_audit_entity" json:"entity_id"`
+	IP         string     `gorm:"type:varchar(45)" json:"ip,omitempty"`
+	RequestID  string     `gorm:"type:varchar(64);index" json:"request_id,omitempty"`
+	Before     JSONMap    `gorm:"type:jsonb" json:"before,omitempty"`
+	After      JSONMap    `gorm:"type:jsonb" json:"after,omitempty"`
+	Details    JSONMap    `gorm:"type:jsonb" json:"details,omitempty"`
+	CreatedAt  time.Time  `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outbound email statuses
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+)
+
+// OutboundEmail is a rendered email waiting in, or delivered from, the
+// outbox. Rows are written in the same transaction as the change that
+// caused them and delivered by the email dispatcher.
+type OutboundEmail struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	ToEmail       string     `gorm:"not null" json:"to_email"`
+	ToName        string     `json:"to_name"`
+	Template      string     `gorm:"type:varchar(50);not null" json:"template"`
+	Subject       string     `gorm:"not null" json:"subject"`
+	HTMLBody      string     `gorm:"type:text" json:"-"`
+	TextBody      string     `gorm:"type:text" json:"-"`
+	Status        string     `gorm:"type:varchar(20);not null;index:idx_outbound_email_due" json:"status"` // pending, sent, failed
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbound_email_due" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
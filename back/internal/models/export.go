@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Data export statuses
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+	ExportStatusExpired    = "expired"
+)
+
+// DataExport is a user's request for a copy of their personal data. The
+// export worker builds the archive and keeps it in storage until ExpiresAt.
+type DataExport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	Status      string     `gorm:"type:varchar(20);not null;index" json:"status"` // pending, processing, ready, failed, expired
+	StorageKey  string     `gorm:"type:varchar(255)" json:"-"`
+	Size        int64      `gorm:"default:0" json:"size"` // bytes of the archive
+	LastError   string     `gorm:"type:text" json:"-"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
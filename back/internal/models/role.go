@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permission is a single capability such as "questions:write"
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Role is a named set of permissions granted to staff accounts
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string       `gorm:"type:varchar(64);uniqueIndex;not null" json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// UserRole grants a role to a user
+type UserRole struct {
+	UserID    uuid.UUID  `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RoleID    uuid.UUID  `gorm:"type:uuid;primaryKey;index" json:"role_id"`
+	GrantedBy *uuid.UUID `gorm:"type:uuid" json:"granted_by,omitempty"`
+	Role      Role       `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// UUIDSet is an unordered set of IDs stored as a PostgreSQL uuid[] column.
+// It is used for answers that select several options at once.
+type UUIDSet []uuid.UUID
+
+// NewUUIDSet returns the distinct IDs in a stable order
+func NewUUIDSet(ids ...uuid.UUID) UUIDSet {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	set := make(UUIDSet, 0, len(ids))
+	for _, id := range ids {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		set = append(set, id)
+	}
+	sort.Slice(set, func(i, j int) bool {
+		return set[i].String() < set[j].String()
+	})
+	return set
+}
+
+// Contains reports whether id is in the set
+func (s UUIDSet) Contains(id uuid.UUID) bool {
+	for _, v := range s {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements driver.Valuer using the PostgreSQL array literal format
+func (s UUIDSet) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	parts := make([]string, len(s))
+	for i, id := range s {
+		parts[i] = id.String()
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
+// Scan implements sql.Scanner for PostgreSQL array literals
+func (s *UUIDSet) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into UUIDSet", value)
+	}
+
+	raw = strings.TrimSpace(raw)
+	if len(raw) < 2 || raw[0] != '{' || raw[len(raw)-1] != '}' {
+		return fmt.Errorf("invalid uuid array: %q", raw)
+	}
+
+	set := UUIDSet{}
+	for _, part := range strings.Split(raw[1:len(raw)-1], ",") {
+		part = strings.Trim(strings.TrimSpace(part), `"`)
+		if part == "" || strings.EqualFold(part, "NULL") {
+			continue
+		}
+		id, err := uuid.Parse(part)
+		if err != nil {
+			return fmt.Errorf("invalid uuid array element %q: %w", part, err)
+		}
+		set = append(set, id)
+	}
+
+	*s = set
+	return nil
+}
+
+// JSONMap is a free-form object stored as a PostgreSQL jsonb column
+type JSONMap map[string]interface{}
+
+// Value implements driver.Valuer
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (m *JSONMap) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONMap", value)
+	}
+	return json.Unmarshal(raw, m)
+}
@@ -0,0 +1,28 @@
+package oauth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/nppe-pro/api/config"
+)
+
+// NewGoogle creates the Google provider
+func NewGoogle(cfg config.GoogleOAuthConfig, client *http.Client) *OIDCProvider {
+	// Google issues ID tokens with and without the scheme in iss
+	issuers := []string{cfg.Issuer}
+	if bare := strings.TrimPrefix(cfg.Issuer, "https://"); bare != cfg.Issuer {
+		issuers = append(issuers, bare)
+	}
+
+	return NewOIDCProvider(OIDCConfig{
+		Name:         "google",
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		AuthURL:      cfg.AuthURL,
+		TokenURL:     cfg.TokenURL,
+		Issuers:      issuers,
+		Scopes:       []string{"openid", "email", "profile"},
+	}, NewJWKS(cfg.JWKSURL, client), client)
+}
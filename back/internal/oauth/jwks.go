@@ -0,0 +1,112 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksRefreshInterval is how long fetched keys are trusted before the set
+// is fetched again. An unknown kid triggers an early refresh, rate limited
+// by jwksMinRefresh.
+const (
+	jwksRefreshInterval = time.Hour
+	jwksMinRefresh      = time.Minute
+)
+
+// JWKS fetches and caches the RSA signing keys published at a JWKS URL
+type JWKS struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// NewJWKS creates a key set backed by url
+func NewJWKS(url string, client *http.Client) *JWKS {
+	return &JWKS{url: url, client: client}
+}
+
+// Key returns the key with the given kid
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if key, ok := j.keys[kid]; ok && time.Since(j.fetched) < jwksRefreshInterval {
+		return key, nil
+	}
+	if j.keys == nil || time.Since(j.fetched) >= jwksMinRefresh {
+		if err := j.refresh(ctx); err != nil {
+			// Fall back to the keys we have
+			if key, ok := j.keys[kid]; ok {
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (j *JWKS) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	j.keys = keys
+	j.fetched = time.Now()
+	return nil
+}
@@ -0,0 +1,182 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCConfig describes an OpenID Connect provider
+type OIDCConfig struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	Issuers      []string // accepted iss values
+	Scopes       []string
+}
+
+// OIDCProvider is a Provider for any OpenID Connect compliant identity
+// provider that signs ID tokens with RS256
+type OIDCProvider struct {
+	config OIDCConfig
+	jwks   *JWKS
+	client *http.Client
+}
+
+// NewOIDCProvider creates a provider whose ID tokens are verified against
+// the keys in jwks
+func NewOIDCProvider(cfg OIDCConfig, jwks *JWKS, client *http.Client) *OIDCProvider {
+	return &OIDCProvider{config: cfg, jwks: jwks, client: client}
+}
+
+// Name implements Provider
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL implements Provider
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(p.config.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.config.AuthURL + sep + q.Encode()
+}
+
+// Exchange implements Provider
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("token exchange failed: status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("token exchange failed: no id_token in response")
+	}
+
+	return p.verify(ctx, body.IDToken, nonce)
+}
+
+// idTokenClaims are the ID token claims we use
+type idTokenClaims struct {
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// verify checks the ID token's signature, issuer, audience, expiry and
+// nonce and returns the identity it asserts
+func (p *OIDCProvider) verify(ctx context.Context, raw, nonce string) (*Identity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.jwks.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if !p.trustedIssuer(claims.Issuer) {
+		return nil, fmt.Errorf("%w: untrusted issuer %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	identity := &Identity{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: bool(claims.EmailVerified),
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+		Picture:       claims.Picture,
+	}
+	if identity.FirstName == "" && identity.LastName == "" && claims.Name != "" {
+		first, last, _ := strings.Cut(claims.Name, " ")
+		identity.FirstName, identity.LastName = first, last
+	}
+	return identity, nil
+}
+
+func (p *OIDCProvider) trustedIssuer(iss string) bool {
+	for _, trusted := range p.config.Issuers {
+		if iss == trusted {
+			return true
+		}
+	}
+	return false
+}
+
+// flexBool decodes booleans that some providers send as strings
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
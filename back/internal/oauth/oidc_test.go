@@ -0,0 +1,220 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const stubClientID = "client-id"
+
+// stubIdP is an identity provider on httptest. It publishes its keys at
+// /jwks and answers /token with an ID token carrying claims, signed with
+// the key named kid.
+type stubIdP struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	kid     string
+	claims  jwt.MapClaims
+	fetches int
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+	idp := &stubIdP{keys: make(map[string]*rsa.PrivateKey)}
+	idp.rotate(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", idp.serveJWKS)
+	mux.HandleFunc("/token", idp.serveToken)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// rotate publishes a new key and signs with it from now on
+func (p *stubIdP) rotate(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[kid] = key
+	p.kid = kid
+}
+
+// issue sets the claims of the next ID token
+func (p *stubIdP) issue(claims jwt.MapClaims) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims = claims
+}
+
+// identity returns valid claims for subject and email
+func (p *stubIdP) identity(nonce, subject, email string, verified bool) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            stubClientID,
+		"sub":            subject,
+		"iat":            now.Unix(),
+		"exp":            now.Add(10 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          email,
+		"email_verified": verified,
+		"given_name":     "Ada",
+		"family_name":    "Lovelace",
+	}
+}
+
+func (p *stubIdP) provider() *OIDCProvider {
+	client := p.server.Client()
+	return NewOIDCProvider(OIDCConfig{
+		Name:         "stub",
+		ClientID:     stubClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.example/auth/stub/callback",
+		AuthURL:      p.server.URL + "/auth",
+		TokenURL:     p.server.URL + "/token",
+		Issuers:      []string{p.server.URL},
+		Scopes:       []string{"openid", "email"},
+	}, NewJWKS(p.server.URL+"/jwks", client), client)
+}
+
+func (p *stubIdP) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetches++
+
+	type jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	for kid, key := range p.keys {
+		set.Keys = append(set.Keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	json.NewEncoder(w).Encode(set)
+}
+
+func (p *stubIdP) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("code_verifier") == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	p.mu.Lock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, p.claims)
+	token.Header["kid"] = p.kid
+	signed, err := token.SignedString(p.keys[p.kid])
+	p.mu.Unlock()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+}
+
+func TestExchange(t *testing.T) {
+	idp := newStubIdP(t)
+
+	tests := []struct {
+		name    string
+		nonce   string // expected by the client
+		change  func(jwt.MapClaims)
+		wantErr bool
+	}{
+		{"valid", "nonce", nil, false},
+		{"email_verified as a string", "nonce", func(c jwt.MapClaims) { c["email_verified"] = "true" }, false},
+		{"bad nonce", "another nonce", nil, true},
+		{"no nonce expected", "", nil, true},
+		{"wrong audience", "nonce", func(c jwt.MapClaims) { c["aud"] = "another-client" }, true},
+		{"wrong issuer", "nonce", func(c jwt.MapClaims) { c["iss"] = "https://issuer.example" }, true},
+		{"expired", "nonce", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, true},
+		{"no subject", "nonce", func(c jwt.MapClaims) { delete(c, "sub") }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.identity("nonce", "subject-1", "Ada@Example.com", true)
+			if tt.change != nil {
+				tt.change(claims)
+			}
+			idp.issue(claims)
+
+			identity, err := idp.provider().Exchange(context.Background(), "code", "verifier", tt.nonce)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("Exchange error = %v, want %v", err, ErrInvalidIDToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if identity.Subject != "subject-1" || identity.Email != "ada@example.com" || !identity.EmailVerified {
+				t.Errorf("identity = %+v", identity)
+			}
+		})
+	}
+}
+
+func TestJWKSRefreshesOnUnknownKid(t *testing.T) {
+	idp := newStubIdP(t)
+	p := idp.provider()
+	ctx := context.Background()
+
+	exchange := func() error {
+		idp.issue(idp.identity("nonce", "subject-1", "ada@example.com", true))
+		_, err := p.Exchange(ctx, "code", "verifier", "nonce")
+		return err
+	}
+
+	if err := exchange(); err != nil {
+		t.Fatalf("Exchange with the first key: %v", err)
+	}
+
+	// A key published right after the last fetch is not fetched again
+	// before jwksMinRefresh
+	idp.rotate(t, "key-2")
+	if err := exchange(); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("Exchange within the refresh limit = %v, want %v", err, ErrInvalidIDToken)
+	}
+
+	p.jwks.mu.Lock()
+	p.jwks.fetched = time.Now().Add(-2 * jwksMinRefresh)
+	p.jwks.mu.Unlock()
+
+	if err := exchange(); err != nil {
+		t.Errorf("Exchange with the rotated key: %v", err)
+	}
+	if idp.fetches != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", idp.fetches)
+	}
+}
@@ -0,0 +1,67 @@
+// Package oauth implements social login with the OAuth 2.0 authorization
+// code flow, PKCE and OpenID Connect ID tokens.
+//
+// Each identity provider implements Provider; the Service drives the flow
+// (state, nonce and PKCE verifier kept in Redis between the redirect and the
+// callback) and links the returned identity to a local user.
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var (
+	// ErrUnknownProvider is returned for providers that are not configured
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	// ErrInvalidState is returned when the callback state is missing,
+	// expired, already used or issued for another provider
+	ErrInvalidState = errors.New("invalid or expired oauth state")
+	// ErrInvalidIDToken is returned when the ID token fails verification
+	ErrInvalidIDToken = errors.New("invalid id token")
+	// ErrEmailNotVerified is returned when an unverified email matches an
+	// existing account, which must not be taken over
+	ErrEmailNotVerified = errors.New("email not verified by provider")
+	// ErrAccountConflict is returned when the matching account is already
+	// linked to a different identity at the same provider
+	ErrAccountConflict = errors.New("account linked to another identity")
+)
+
+// Identity is the verified identity returned by a provider
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	Picture       string
+}
+
+// Provider is an OAuth identity provider
+type Provider interface {
+	// Name is the value stored in User.OAuthProvider
+	Name() string
+	// AuthCodeURL returns the URL that starts the flow at the provider
+	AuthCodeURL(state, nonce, codeChallenge string) string
+	// Exchange trades the authorization code for a verified identity. The
+	// ID token must carry nonce.
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+// randomToken returns n random bytes, base64url encoded
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge derives the S256 PKCE challenge for verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,206 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/tokens"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// stateTTL bounds how long a user may take at the provider's consent screen
+const stateTTL = 10 * time.Minute
+
+// flow is what is remembered between the redirect and the callback
+type flow struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+// Service runs the login flow for the configured providers
+type Service struct {
+	db        *gorm.DB
+	redis     *database.RedisClient
+	tokens    *tokens.Store
+	providers map[string]Provider
+}
+
+// NewService creates a service with every provider that is configured
+func NewService(db *gorm.DB, redis *database.RedisClient, cfg *config.Config) *Service {
+	s := &Service{db: db, redis: redis, tokens: tokens.NewStore(redis, cfg), providers: make(map[string]Provider)}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	if cfg.OAuth.Google.ClientID != "" {
+		s.Register(NewGoogle(cfg.OAuth.Google, client))
+	}
+	return s
+}
+
+// Register adds or replaces a provider
+func (s *Service) Register(p Provider) {
+	s.providers[p.Name()] = p
+}
+
+// Begin starts a login with the named provider and returns the URL to
+// redirect the user to
+func (s *Service) Begin(ctx context.Context, provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken(48)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(flow{Provider: provider, Verifier: verifier, Nonce: nonce})
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.Set(ctx, stateKey(state), data, stateTTL); err != nil {
+		return "", fmt.Errorf("failed to store oauth state: %w", err)
+	}
+
+	return p.AuthCodeURL(state, nonce, codeChallenge(verifier)), nil
+}
+
+// Complete finishes a login from the provider's callback and returns the
+// local user, creating it on first login. created reports whether it did.
+func (s *Service) Complete(ctx context.Context, provider, state, code string) (user *models.User, created bool, err error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, false, ErrUnknownProvider
+	}
+
+	// The state is single use: take it out of Redis atomically
+	raw, err := s.redis.Client.GetDel(ctx, stateKey(state)).Result()
+	if errors.Is(err, redis.Nil) || state == "" {
+		return nil, false, ErrInvalidState
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load oauth state: %w", err)
+	}
+	var f flow
+	if err := json.Unmarshal([]byte(raw), &f); err != nil || f.Provider != provider {
+		return nil, false, ErrInvalidState
+	}
+
+	identity, err := p.Exchange(ctx, code, f.Verifier, f.Nonce)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return s.link(ctx, provider, identity)
+}
+
+// link finds the user for identity: an account already linked to it, an
+// account with the same verified email (which gets linked), or a new one.
+//
+// An account whose email was never verified may have been registered by
+// someone else ahead of its owner. Linking it proves the owner controls the
+// email, so whatever the registrant set up is thrown away: the password,
+// two-factor enrolment, a pending email change and every session.
+func (s *Service) link(ctx context.Context, provider string, identity *Identity) (*models.User, bool, error) {
+	var user models.User
+	created, claimed := false, false
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("o_auth_provider = ? AND o_auth_id = ?", provider, identity.Subject).First(&user).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if identity.Email == "" {
+			return fmt.Errorf("%w: no email in id token", ErrInvalidIDToken)
+		}
+
+		err = tx.Where("LOWER(email) = ?", identity.Email).First(&user).Error
+		switch {
+		case err == nil:
+			if !identity.EmailVerified {
+				return ErrEmailNotVerified
+			}
+			if user.OAuthProvider == provider && user.OAuthID != "" && user.OAuthID != identity.Subject {
+				return ErrAccountConflict
+			}
+			updates := map[string]interface{}{
+				"o_auth_provider": provider,
+				"o_auth_id":       identity.Subject,
+				"is_verified":     true,
+			}
+			if user.AvatarURL == "" && identity.Picture != "" {
+				updates["avatar_url"] = identity.Picture
+			}
+			if !user.IsVerified {
+				claimed = true
+				updates["password_hash"] = ""
+				updates["pending_email"] = ""
+				updates["mfa_secret"] = ""
+				updates["mfa_enabled_at"] = nil
+				updates["mfa_last_step"] = 0
+				if err := tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&user).Updates(updates).Error
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		// First login: create the account. It has no password until the
+		// user sets one through the reset flow.
+		user = models.User{
+			Email:         identity.Email,
+			FirstName:     identity.FirstName,
+			LastName:      identity.LastName,
+			IsVerified:    identity.EmailVerified,
+			AvatarURL:     identity.Picture,
+			OAuthProvider: provider,
+			OAuthID:       identity.Subject,
+		}
+		if user.FirstName == "" {
+			user.FirstName, _, _ = strings.Cut(identity.Email, "@")
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		created = true
+		return tx.Create(&models.UserStats{UserID: user.ID}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if claimed {
+		if err := s.tokens.RevokeAll(ctx, user.ID); err != nil {
+			return nil, false, fmt.Errorf("failed to revoke sessions of claimed account: %w", err)
+		}
+	}
+	return &user, created, nil
+}
+
+func stateKey(state string) string {
+	return "oauth_state:" + state
+}
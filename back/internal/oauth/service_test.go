@@ -0,0 +1,240 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/models"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/tokens"
+	"gorm.io/gorm"
+)
+
+// newTestService connects to the database and Redis named by
+// TEST_DATABASE_URL and TEST_REDIS_URL and skips the test without them
+func newTestService(t *testing.T, idp *stubIdP) (*Service, *gorm.DB) {
+	t.Helper()
+	dbURL, redisURL := os.Getenv("TEST_DATABASE_URL"), os.Getenv("TEST_REDIS_URL")
+	if dbURL == "" || redisURL == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_REDIS_URL are not set")
+	}
+
+	cfg := &config.Config{
+		Server:   config.ServerConfig{Environment: "test"},
+		Database: config.DatabaseConfig{URL: dbURL, MaxIdleConns: 1, MaxOpenConns: 2, ConnMaxLifetime: time.Minute},
+		Redis:    config.RedisConfig{URL: redisURL},
+		JWT:      config.JWTConfig{RefreshExpiration: time.Hour},
+	}
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.EnableExtensions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	redis, err := database.NewRedisClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redis.Close() })
+
+	s := NewService(db.DB, redis, cfg)
+	s.Register(idp.provider())
+	return s, db.DB
+}
+
+// login runs the flow from Begin to Complete with the identity idp returns
+func login(t *testing.T, s *Service, idp *stubIdP, subject, email string, verified bool) (*models.User, bool, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	authURL, err := s.Begin(ctx, "stub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+
+	idp.issue(idp.identity(query.Get("nonce"), subject, email, verified))
+	return s.Complete(ctx, "stub", query.Get("state"), "code")
+}
+
+// createUser stores user and removes it with its rows when the test ends
+func createUser(t *testing.T, db *gorm.DB, user *models.User) {
+	t.Helper()
+	user.FirstName, user.LastName, user.Province = "Ada", "Lovelace", "BC"
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	cleanupUser(t, db, user.Email)
+}
+
+func cleanupUser(t *testing.T, db *gorm.DB, email string) {
+	t.Cleanup(func() {
+		var user models.User
+		if err := db.Unscoped().Where("email = ?", email).First(&user).Error; err != nil {
+			return
+		}
+		db.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{})
+		db.Where("user_id = ?", user.ID).Delete(&models.UserStats{})
+		db.Unscoped().Delete(&user)
+	})
+}
+
+func testEmail() string {
+	return "oauth-" + uuid.NewString() + "@example.com"
+}
+
+func TestCompleteCreatesAccount(t *testing.T) {
+	idp := newStubIdP(t)
+	s, db := newTestService(t, idp)
+
+	email := testEmail()
+	cleanupUser(t, db, email)
+	subject := uuid.NewString()
+
+	user, created, err := login(t, s, idp, subject, email, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || user.OAuthProvider != "stub" || user.OAuthID != subject || !user.IsVerified {
+		t.Errorf("created = %v, user = %+v", created, user)
+	}
+
+	// The next login finds the account by its identity
+	again, created, err := login(t, s, idp, subject, email, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || again.ID != user.ID {
+		t.Errorf("second login created = %v, user %s, want existing %s", created, again.ID, user.ID)
+	}
+}
+
+func TestCompleteStateIsSingleUse(t *testing.T) {
+	idp := newStubIdP(t)
+	s, db := newTestService(t, idp)
+	ctx := context.Background()
+
+	email := testEmail()
+	cleanupUser(t, db, email)
+
+	authURL, err := s.Begin(ctx, "stub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _ := url.Parse(authURL)
+	state := parsed.Query().Get("state")
+	idp.issue(idp.identity(parsed.Query().Get("nonce"), uuid.NewString(), email, true))
+
+	if _, _, err := s.Complete(ctx, "stub", state, "code"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Complete(ctx, "stub", state, "code"); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("replayed state = %v, want %v", err, ErrInvalidState)
+	}
+}
+
+func TestCompleteLinksVerifiedEmail(t *testing.T) {
+	idp := newStubIdP(t)
+	s, db := newTestService(t, idp)
+
+	existing := &models.User{Email: testEmail(), PasswordHash: "hash", IsVerified: true}
+	createUser(t, db, existing)
+
+	user, created, err := login(t, s, idp, uuid.NewString(), existing.Email, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || user.ID != existing.ID {
+		t.Fatalf("created = %v, user %s, want existing %s", created, user.ID, existing.ID)
+	}
+
+	var stored models.User
+	db.First(&stored, "id = ?", existing.ID)
+	if stored.OAuthProvider != "stub" || stored.PasswordHash != "hash" {
+		t.Errorf("linked account = provider %q, password %q; want stub and the password kept", stored.OAuthProvider, stored.PasswordHash)
+	}
+}
+
+func TestCompleteRefusesUnverifiedEmail(t *testing.T) {
+	idp := newStubIdP(t)
+	s, db := newTestService(t, idp)
+
+	existing := &models.User{Email: testEmail(), PasswordHash: "hash", IsVerified: true}
+	createUser(t, db, existing)
+
+	if _, _, err := login(t, s, idp, uuid.NewString(), existing.Email, false); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("login = %v, want %v", err, ErrEmailNotVerified)
+	}
+
+	var stored models.User
+	db.First(&stored, "id = ?", existing.ID)
+	if stored.OAuthID != "" {
+		t.Errorf("account was linked to %q", stored.OAuthID)
+	}
+}
+
+func TestCompleteClaimsUnverifiedAccount(t *testing.T) {
+	idp := newStubIdP(t)
+	s, db := newTestService(t, idp)
+	ctx := context.Background()
+
+	// Registered by someone else ahead of the owner, who never verified it
+	enabled := time.Now()
+	existing := &models.User{
+		Email:        testEmail(),
+		PasswordHash: "hash",
+		PendingEmail: testEmail(),
+		MFASecret:    "secret",
+		MFAEnabledAt: &enabled,
+	}
+	createUser(t, db, existing)
+	if err := db.Create(&models.MFARecoveryCode{UserID: existing.ID, CodeHash: uuid.NewString()}).Error; err != nil {
+		t.Fatal(err)
+	}
+	sessionID := uuid.New()
+	if err := s.tokens.StartFamily(ctx, existing.ID, sessionID, uuid.NewString(), tokens.Client{}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, created, err := login(t, s, idp, uuid.NewString(), existing.Email, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || user.ID != existing.ID {
+		t.Fatalf("created = %v, user %s, want existing %s", created, user.ID, existing.ID)
+	}
+
+	var stored models.User
+	db.First(&stored, "id = ?", existing.ID)
+	if stored.PasswordHash != "" || stored.PendingEmail != "" || stored.MFASecret != "" || stored.MFAEnabledAt != nil {
+		t.Errorf("claimed account kept the registrant's credentials: %+v", stored)
+	}
+	if !stored.IsVerified {
+		t.Error("claimed account is not verified")
+	}
+
+	var codes int64
+	db.Model(&models.MFARecoveryCode{}).Where("user_id = ?", existing.ID).Count(&codes)
+	if codes != 0 {
+		t.Errorf("%d recovery codes left", codes)
+	}
+	if _, err := s.tokens.Session(ctx, existing.ID, sessionID); !errors.Is(err, tokens.ErrSessionNotFound) {
+		t.Errorf("registrant's session = %v, want %v", err, tokens.ErrSessionNotFound)
+	}
+}
@@ -0,0 +1,210 @@
+// Package practice records answers given outside of formal tests.
+package practice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/grading"
+	"github.com/nppe-pro/api/internal/mastery"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrQuestionNotFound is returned for unknown or inactive questions and
+	// for questions of another province
+	ErrQuestionNotFound = errors.New("question not found")
+	// ErrQuestionInTest is returned for a question of a test the user has in
+	// progress, whose answer key must stay hidden until the test ends
+	ErrQuestionInTest = errors.New("question is part of a test in progress")
+)
+
+// maxTimeSpent caps the client-reported time on a single question
+const maxTimeSpent = time.Hour
+
+// Service grades practice answers and keeps the user's history up to date
+type Service struct {
+	db     *gorm.DB
+	config *config.Config
+	bus    *events.Bus
+}
+
+// NewService creates a new practice service. Recorded answers and newly
+// mastered topics are announced on bus, which may be nil.
+func NewService(db *gorm.DB, cfg *config.Config, bus *events.Bus) *Service {
+	return &Service{db: db, config: cfg, bus: bus}
+}
+
+// Attempt is one practice answer
+type Attempt struct {
+	UserID           uuid.UUID
+	QuestionID       uuid.UUID
+	Selected         models.UUIDSet
+	TimeSpentSeconds int
+}
+
+// Result is the graded attempt together with what it changed
+type Result struct {
+	Answer   *models.UserAnswer
+	Question *models.Question
+	Outcome  grading.Outcome
+	Mastery  *models.UserTopicMastery
+}
+
+// Answer grades an attempt and, in one transaction, stores the UserAnswer,
+// updates UserStats and folds the attempt into the topic mastery.
+// Validation errors from the grading package are returned unwrapped.
+func (s *Service) Answer(ctx context.Context, attempt Attempt) (*Result, error) {
+	db := s.db.WithContext(ctx)
+
+	var user models.User
+	if err := db.Select("id", "province").First(&user, "id = ?", attempt.UserID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	query := db.Preload("Topic").
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("id = ? AND is_active = ?", attempt.QuestionID, true)
+	if user.Province != "" {
+		query = query.Where("province IS NULL OR province = ?", user.Province)
+	}
+
+	var question models.Question
+	if err := query.First(&question).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuestionNotFound
+		}
+		return nil, fmt.Errorf("failed to load question: %w", err)
+	}
+
+	// Grading here would reveal the key of a test that is still running
+	var inTest int64
+	if err := db.Table("practice_test_questions ptq").
+		Joins("JOIN practice_tests pt ON pt.id = ptq.practice_test_id").
+		Where("pt.user_id = ? AND pt.status = ? AND ptq.question_id = ?", attempt.UserID, "in_progress", question.ID).
+		Count(&inTest).Error; err != nil {
+		return nil, fmt.Errorf("failed to check tests in progress: %w", err)
+	}
+	if inTest > 0 {
+		return nil, ErrQuestionInTest
+	}
+
+	if err := grading.Validate(&question, attempt.Selected); err != nil {
+		return nil, err
+	}
+
+	outcome := grading.Grade(&question, attempt.Selected, s.config.Exam.MultiSelectMode)
+	timeSpent := attempt.TimeSpentSeconds
+	if timeSpent < 0 {
+		timeSpent = 0
+	} else if limit := int(maxTimeSpent.Seconds()); timeSpent > limit {
+		timeSpent = limit
+	}
+	now := time.Now()
+
+	answer := &models.UserAnswer{
+		UserID:            attempt.UserID,
+		QuestionID:        question.ID,
+		SelectedOptionIDs: attempt.Selected,
+		IsCorrect:         outcome.Correct,
+		TimeSpentSeconds:  timeSpent,
+	}
+	if !grading.IsMultiSelect(question.QuestionType) {
+		answer.SelectedOptionID = &attempt.Selected[0]
+	}
+
+	var topicMastery models.UserTopicMastery
+	newlyMastered := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(answer).Error; err != nil {
+			return fmt.Errorf("failed to save answer: %w", err)
+		}
+
+		correct := 0
+		if outcome.Correct {
+			correct = 1
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.UserStats{UserID: attempt.UserID}).Error; err != nil {
+			return fmt.Errorf("failed to create user stats: %w", err)
+		}
+		if err := tx.Model(&models.UserStats{}).
+			Where("user_id = ?", attempt.UserID).
+			Updates(map[string]interface{}{
+				"questions_completed":  gorm.Expr("questions_completed + 1"),
+				"questions_correct":    gorm.Expr("questions_correct + ?", correct),
+				"time_studied_seconds": gorm.Expr("time_studied_seconds + ?", timeSpent),
+				"updated_at":           now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update user stats: %w", err)
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.UserTopicMastery{
+				UserID:        attempt.UserID,
+				TopicID:       question.TopicID,
+				LastPracticed: now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to create topic mastery: %w", err)
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND topic_id = ?", attempt.UserID, question.TopicID).
+			First(&topicMastery).Error; err != nil {
+			return fmt.Errorf("failed to load topic mastery: %w", err)
+		}
+
+		wasMastered := mastery.Mastered(&topicMastery)
+		mastery.Record(&topicMastery, outcome.Correct, outcome.Credit, now)
+		newlyMastered = !wasMastered && mastery.Mastered(&topicMastery)
+
+		if err := tx.Save(&topicMastery).Error; err != nil {
+			return fmt.Errorf("failed to update topic mastery: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(ctx, events.Event{
+		Type:       events.AnswerRecorded,
+		UserID:     attempt.UserID,
+		OccurredAt: now,
+		Payload: events.AnswerRecordedPayload{
+			AnswerID:   answer.ID,
+			QuestionID: question.ID,
+			TopicID:    question.TopicID,
+			Correct:    outcome.Correct,
+		},
+	})
+
+	if newlyMastered {
+		s.bus.Publish(ctx, events.Event{
+			Type:       events.TopicMastered,
+			UserID:     attempt.UserID,
+			OccurredAt: now,
+			Payload: events.TopicMasteredPayload{
+				TopicID:           question.TopicID,
+				MasteryPercentage: topicMastery.MasteryPercentage,
+			},
+		})
+	}
+
+	return &Result{
+		Answer:   answer,
+		Question: &question,
+		Outcome:  outcome,
+		Mastery:  &topicMastery,
+	}, nil
+}
@@ -0,0 +1,43 @@
+// Package province lists the Canadian provinces and territories and the
+// body that licenses professional engineers in each
+package province
+
+import "strings"
+
+// Regulator is the engineering regulator of one province or territory
+type Regulator struct {
+	Code      string `json:"code"` // two-letter postal abbreviation
+	Province  string `json:"province"`
+	Name      string `json:"name"`
+	ShortName string `json:"short_name"`
+}
+
+// Regulators covers every province and territory. The Northwest
+// Territories and Nunavut share a regulator.
+var Regulators = []Regulator{
+	{"AB", "Alberta", "Association of Professional Engineers and Geoscientists of Alberta", "APEGA"},
+	{"BC", "British Columbia", "Engineers and Geoscientists British Columbia", "EGBC"},
+	{"MB", "Manitoba", "Engineers Geoscientists Manitoba", "EGM"},
+	{"NB", "New Brunswick", "Engineers and Geoscientists New Brunswick", "EGNB"},
+	{"NL", "Newfoundland and Labrador", "Professional Engineers and Geoscientists Newfoundland and Labrador", "PEGNL"},
+	{"NS", "Nova Scotia", "Engineers Nova Scotia", "Engineers Nova Scotia"},
+	{"NT", "Northwest Territories", "Northwest Territories and Nunavut Association of Professional Engineers and Geoscientists", "NAPEG"},
+	{"NU", "Nunavut", "Northwest Territories and Nunavut Association of Professional Engineers and Geoscientists", "NAPEG"},
+	{"ON", "Ontario", "Professional Engineers Ontario", "PEO"},
+	{"PE", "Prince Edward Island", "Engineers PEI", "Engineers PEI"},
+	{"QC", "Quebec", "Ordre des ingénieurs du Québec", "OIQ"},
+	{"SK", "Saskatchewan", "Association of Professional Engineers and Geoscientists of Saskatchewan", "APEGS"},
+	{"YT", "Yukon", "Engineers Yukon", "Engineers Yukon"},
+}
+
+// Lookup finds the regulator for a province code, ignoring case and
+// surrounding spaces
+func Lookup(code string) (Regulator, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, r := range Regulators {
+		if r.Code == code {
+			return r, true
+		}
+	}
+	return Regulator{}, false
+}
@@ -0,0 +1,134 @@
+package rbac
+
+import (
+	"fmt"
+
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Permissions
+const (
+	PermQuestionsRead    = "questions:read"
+	PermQuestionsWrite   = "questions:write"
+	PermQuestionsPublish = "questions:publish"
+	PermQuestionsDelete  = "questions:delete"
+	PermUsersRead        = "users:read"
+	PermUsersUnlock      = "users:unlock"
+	PermUsersDelete      = "users:delete"
+	PermBillingManage    = "billing:manage"
+	PermForumModerate    = "forum:moderate"
+	PermStatisticsRead   = "statistics:read"
+	PermRolesManage      = "roles:manage"
+	PermAuditRead        = "audit:read"
+)
+
+// Roles
+const (
+	RoleContentAuthor   = "content_author"
+	RoleContentReviewer = "content_reviewer"
+	RoleModerator       = "moderator"
+	RoleSupport         = "support"
+	RoleBillingAdmin    = "billing_admin"
+	RoleSuperAdmin      = "super_admin"
+)
+
+// DefaultPermissions are seeded on migration
+var DefaultPermissions = []models.Permission{
+	{Name: PermQuestionsRead, Description: "View every question, including inactive ones"},
+	{Name: PermQuestionsWrite, Description: "Create and edit questions"},
+	{Name: PermQuestionsPublish, Description: "Activate and deactivate questions in bulk"},
+	{Name: PermQuestionsDelete, Description: "Delete questions"},
+	{Name: PermUsersRead, Description: "View user accounts"},
+	{Name: PermUsersUnlock, Description: "Lift login lockouts"},
+	{Name: PermUsersDelete, Description: "Delete user accounts"},
+	{Name: PermBillingManage, Description: "Manage subscriptions and payments"},
+	{Name: PermForumModerate, Description: "Edit and remove forum posts"},
+	{Name: PermStatisticsRead, Description: "View platform statistics"},
+	{Name: PermRolesManage, Description: "Grant and revoke roles"},
+	{Name: PermAuditRead, Description: "View and export the audit log"},
+}
+
+// roleDefinition is a default role and its permissions
+type roleDefinition struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// DefaultRoles are seeded on migration. super_admin holds every permission.
+var DefaultRoles = []roleDefinition{
+	{RoleContentAuthor, "Writes and edits questions", []string{PermQuestionsRead, PermQuestionsWrite}},
+	{RoleContentReviewer, "Reviews, publishes and retires questions", []string{PermQuestionsRead, PermQuestionsWrite, PermQuestionsPublish, PermQuestionsDelete}},
+	{RoleModerator, "Moderates the community forum", []string{PermForumModerate, PermUsersRead}},
+	{RoleSupport, "Helps users with their accounts", []string{PermUsersRead, PermUsersUnlock}},
+	{RoleBillingAdmin, "Manages subscriptions and payments", []string{PermBillingManage, PermUsersRead}},
+	{RoleSuperAdmin, "Full access", nil},
+}
+
+// Seed inserts the default permissions and roles and grants each default
+// role its permissions. Users flagged by the legacy is_admin column become
+// super admins once, after which the column is dropped so a later
+// migration cannot restore rights taken away through SetRoles.
+func Seed(db *gorm.DB) error {
+	onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}
+
+	permissions := append([]models.Permission(nil), DefaultPermissions...)
+	if err := db.Clauses(onConflict).Create(&permissions).Error; err != nil {
+		return fmt.Errorf("failed to seed permissions: %w", err)
+	}
+
+	var stored []models.Permission
+	if err := db.Find(&stored).Error; err != nil {
+		return fmt.Errorf("failed to load permissions: %w", err)
+	}
+	byName := make(map[string]models.Permission, len(stored))
+	for _, p := range stored {
+		byName[p.Name] = p
+	}
+
+	for _, def := range DefaultRoles {
+		role := models.Role{Name: def.Name, Description: def.Description}
+		if err := db.Clauses(onConflict).Create(&role).Error; err != nil {
+			return fmt.Errorf("failed to seed role %s: %w", def.Name, err)
+		}
+		if err := db.Where("name = ?", def.Name).First(&role).Error; err != nil {
+			return fmt.Errorf("failed to load role %s: %w", def.Name, err)
+		}
+
+		var grants []models.Permission
+		if def.Name == RoleSuperAdmin {
+			grants = stored
+		} else {
+			for _, name := range def.Permissions {
+				p, ok := byName[name]
+				if !ok {
+					return fmt.Errorf("role %s uses unknown permission %s", def.Name, name)
+				}
+				grants = append(grants, p)
+			}
+		}
+		if err := db.Model(&role).Association("Permissions").Append(grants); err != nil {
+			return fmt.Errorf("failed to grant permissions to %s: %w", def.Name, err)
+		}
+	}
+
+	if !db.Migrator().HasColumn("users", "is_admin") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO user_roles (user_id, role_id, created_at)
+			SELECT u.id, r.id, NOW()
+			FROM users u JOIN roles r ON r.name = ?
+			WHERE u.is_admin AND u.deleted_at IS NULL
+			ON CONFLICT DO NOTHING`, RoleSuperAdmin).Error; err != nil {
+			return fmt.Errorf("failed to migrate admins to roles: %w", err)
+		}
+		if err := tx.Migrator().DropColumn("users", "is_admin"); err != nil {
+			return fmt.Errorf("failed to drop is_admin: %w", err)
+		}
+		return nil
+	})
+}
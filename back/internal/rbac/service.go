@@ -0,0 +1,168 @@
+// Package rbac implements role-based access control. Roles are named sets
+// of permissions kept in the database; a user's roles and permissions are
+// embedded in their access token and checked by
+// middleware.RequirePermission.
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/audit"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnknownRole is returned when assigning a role that does not exist
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrLastSuperAdmin is returned when a change would leave no super admin
+	ErrLastSuperAdmin = errors.New("cannot remove the last super admin")
+)
+
+// Grants are the roles and permissions held by a user
+type Grants struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Staff reports whether the user holds any role
+func (g Grants) Staff() bool {
+	return len(g.Roles) > 0
+}
+
+// Service reads and changes role assignments
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService creates a new RBAC service
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Grants returns the roles and permissions held by userID
+func (s *Service) Grants(ctx context.Context, userID uuid.UUID) (Grants, error) {
+	db := s.db.WithContext(ctx)
+	grants := Grants{Roles: []string{}, Permissions: []string{}}
+
+	if err := db.Table("roles").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Pluck("roles.name", &grants.Roles).Error; err != nil {
+		return Grants{}, fmt.Errorf("failed to load roles: %w", err)
+	}
+	if len(grants.Roles) == 0 {
+		return grants, nil
+	}
+
+	if err := db.Table("permissions").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Distinct().
+		Order("permissions.name").
+		Pluck("permissions.name", &grants.Permissions).Error; err != nil {
+		return Grants{}, fmt.Errorf("failed to load permissions: %w", err)
+	}
+	return grants, nil
+}
+
+// IsStaff reports whether userID holds any role
+func (s *Service) IsStaff(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.UserRole{}).Where("user_id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// Roles returns every role with its permissions
+func (s *Service) Roles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := s.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+// SetRoles replaces the roles of userID with the named ones and audits the
+// change as the given actor
+func (s *Service) SetRoles(ctx context.Context, userID uuid.UUID, names []string, actor audit.Actor) (Grants, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var roles []models.Role
+		if err := tx.Where("name IN ?", names).Find(&roles).Error; err != nil {
+			return err
+		}
+		if len(roles) != len(uniqueStrings(names)) {
+			return ErrUnknownRole
+		}
+
+		var before []string
+		if err := tx.Table("roles").
+			Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+			Where("user_roles.user_id = ?", userID).
+			Order("roles.name").
+			Pluck("roles.name", &before).Error; err != nil {
+			return err
+		}
+
+		after := make([]string, len(roles))
+		for i, r := range roles {
+			after[i] = r.Name
+		}
+		sort.Strings(after)
+
+		if contains(before, RoleSuperAdmin) && !contains(after, RoleSuperAdmin) {
+			var others int64
+			if err := tx.Model(&models.UserRole{}).
+				Joins("JOIN roles ON roles.id = user_roles.role_id").
+				Where("roles.name = ? AND user_roles.user_id <> ?", RoleSuperAdmin, userID).
+				Count(&others).Error; err != nil {
+				return err
+			}
+			if others == 0 {
+				return ErrLastSuperAdmin
+			}
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		for _, r := range roles {
+			if err := tx.Create(&models.UserRole{UserID: userID, RoleID: r.ID, GrantedBy: actor.ID}).Error; err != nil {
+				return err
+			}
+		}
+
+		entry := actor.Entry(models.AuditRolesChanged, "user", userID.String())
+		entry.Before = map[string]interface{}{"roles": before}
+		entry.After = map[string]interface{}{"roles": after}
+		return audit.Record(tx, entry)
+	})
+	if err != nil {
+		return Grants{}, err
+	}
+	return s.Grants(ctx, userID)
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
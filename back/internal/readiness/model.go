@@ -0,0 +1,249 @@
+// Package readiness estimates the probability that a user passes the NPPE.
+//
+// Two independent estimates of the user's expected exam score are combined
+// by inverse-variance weighting:
+//
+//   - topic mastery weighted by the exam blueprint, shrunk towards a prior
+//     for topics with few attempts and adjusted for the difficulty of the
+//     questions the user has been answering compared with the exam mix;
+//   - recency-weighted scores of recent full practice exams.
+//
+// The pass probability is the chance that a score drawn around the combined
+// estimate, with the sampling noise of a real exam, reaches the pass mark.
+// The confidence interval propagates the uncertainty of the estimate itself.
+package readiness
+
+import (
+	"math"
+)
+
+const (
+	// priorMastery and priorWeight shrink thin topic evidence towards a
+	// coin flip: a topic behaves as if it had priorWeight extra attempts
+	priorMastery = 0.5
+	priorWeight  = 5.0
+	// examDecay is the weight of each older full exam relative to the next
+	examDecay = 0.6
+	// modelVariance is a floor on the estimate variance covering what the
+	// model does not capture
+	modelVariance = 0.03 * 0.03
+	// maxDifficultyAdjustment bounds the difficulty correction factor
+	maxDifficultyAdjustment = 0.15
+	// z95 is the two-sided 95% normal quantile
+	z95 = 1.96
+)
+
+// Confidence levels describe how much evidence backs an estimate
+const (
+	ConfidenceNone   = "none"
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+// Input is the evidence the model needs
+type Input struct {
+	Topics        []TopicEvidence
+	Difficulty    map[string]DifficultyEvidence // keyed by easy, medium, hard
+	DifficultyMix map[string]float64            // share of each difficulty on the exam
+	Exams         []ExamEvidence                // newest first
+	PassMark      float64                       // percentage
+	ExamQuestions int                           // questions on the real exam
+}
+
+// TopicEvidence is the user's mastery of one blueprint topic
+type TopicEvidence struct {
+	Weight   float64
+	Mastery  float64 // percentage
+	Attempts int
+}
+
+// DifficultyEvidence is the user's record at one difficulty level
+type DifficultyEvidence struct {
+	Attempts int
+	Credit   float64
+}
+
+// ExamEvidence is one completed full practice exam
+type ExamEvidence struct {
+	Score     float64 // percentage
+	Questions int
+}
+
+// Estimate is the model output. Percentages are in [0, 100].
+type Estimate struct {
+	PassProbability float64 `json:"pass_probability"`
+	Lower           float64 `json:"lower"`
+	Upper           float64 `json:"upper"`
+	ExpectedScore   float64 `json:"expected_score"`
+	PassMark        float64 `json:"pass_mark"`
+	Confidence      string  `json:"confidence"`
+	Attempts        int     `json:"questions_attempted"`
+	ExamsConsidered int     `json:"exams_considered"`
+}
+
+// Predict estimates the pass probability from in
+func Predict(in Input) Estimate {
+	est := Estimate{PassMark: in.PassMark, Confidence: ConfidenceNone, ExamsConsidered: len(in.Exams)}
+	for _, t := range in.Topics {
+		est.Attempts += t.Attempts
+	}
+	if est.Attempts == 0 && len(in.Exams) == 0 {
+		return est
+	}
+
+	var means, variances []float64
+	if est.Attempts > 0 {
+		mean, variance := masteryEstimate(in.Topics)
+		mean = clamp(mean*difficultyAdjustment(in.Difficulty, in.DifficultyMix), 0, 1)
+		means, variances = append(means, mean), append(variances, variance)
+	}
+	if len(in.Exams) > 0 {
+		mean, variance := examEstimate(in.Exams)
+		means, variances = append(means, mean), append(variances, variance)
+	}
+
+	// Inverse-variance weighting of the independent estimates
+	precision, weighted := 0.0, 0.0
+	for i := range means {
+		v := math.Max(variances[i], 1e-6)
+		precision += 1 / v
+		weighted += means[i] / v
+	}
+	mean := weighted / precision
+	variance := 1/precision + modelVariance
+
+	pass := in.PassMark / 100
+	sd := math.Sqrt(variance)
+	est.ExpectedScore = round1(mean * 100)
+	est.PassProbability = round1(passProbability(mean, variance, pass, in.ExamQuestions) * 100)
+	// The bounds already move the mean by the estimate's uncertainty, so
+	// only the sampling noise of the exam is left to add
+	est.Lower = round1(passProbability(clamp(mean-z95*sd, 0, 1), 0, pass, in.ExamQuestions) * 100)
+	est.Upper = round1(passProbability(clamp(mean+z95*sd, 0, 1), 0, pass, in.ExamQuestions) * 100)
+	est.Confidence = confidence(sd)
+	return est
+}
+
+// masteryEstimate returns the blueprint-weighted mastery and its variance
+func masteryEstimate(topics []TopicEvidence) (float64, float64) {
+	weightSum := 0.0
+	for _, t := range topics {
+		weightSum += t.Weight
+	}
+
+	mean, variance := 0.0, 0.0
+	for _, t := range topics {
+		w := 1 / float64(len(topics))
+		if weightSum > 0 {
+			w = t.Weight / weightSum
+		}
+		n := float64(t.Attempts)
+		m := (n*t.Mastery/100 + priorWeight*priorMastery) / (n + priorWeight)
+		mean += w * m
+		variance += w * w * m * (1 - m) / (n + priorWeight)
+	}
+	return mean, variance
+}
+
+// difficultyAdjustment scales practice performance to the exam's difficulty
+// mix. A user who has mostly answered easy questions gets marked down, one
+// who has mostly answered hard questions gets marked up.
+func difficultyAdjustment(evidence map[string]DifficultyEvidence, mix map[string]float64) float64 {
+	attempts, credit := 0, 0.0
+	for _, e := range evidence {
+		attempts += e.Attempts
+		credit += e.Credit
+	}
+	if attempts == 0 || credit == 0 {
+		return 1
+	}
+	overall := credit / float64(attempts)
+
+	// Expected accuracy on the exam mix, using the overall accuracy for
+	// difficulties the user has not tried
+	expected, mixSum := 0.0, 0.0
+	for d, share := range mix {
+		acc := overall
+		if e, ok := evidence[d]; ok && e.Attempts > 0 {
+			n := float64(e.Attempts)
+			acc = (e.Credit + priorWeight*overall) / (n + priorWeight)
+		}
+		expected += share * acc
+		mixSum += share
+	}
+	if mixSum == 0 {
+		return 1
+	}
+
+	return clamp(expected/mixSum/overall, 1-maxDifficultyAdjustment, 1+maxDifficultyAdjustment)
+}
+
+// examEstimate returns the recency-weighted full exam score and its variance
+func examEstimate(exams []ExamEvidence) (float64, float64) {
+	weight, weightSum, questions := 1.0, 0.0, 0.0
+	mean := 0.0
+	for _, e := range exams {
+		mean += weight * e.Score / 100
+		questions += weight * float64(e.Questions)
+		weightSum += weight
+		weight *= examDecay
+	}
+	mean /= weightSum
+	if questions < 1 {
+		questions = 1
+	}
+
+	// Sampling noise of the exams plus their spread around the mean
+	variance := mean * (1 - mean) / questions
+	if len(exams) > 1 {
+		spread := 0.0
+		weight = 1
+		for _, e := range exams {
+			d := e.Score/100 - mean
+			spread += weight * d * d
+			weight *= examDecay
+		}
+		variance += spread / weightSum / float64(len(exams))
+	}
+	return mean, variance
+}
+
+// passProbability is P(score >= pass) for a real exam of n questions when
+// the expected score is mean with estimate variance variance
+func passProbability(mean, variance, pass float64, n int) float64 {
+	total := variance
+	if n > 0 {
+		total += mean * (1 - mean) / float64(n)
+	}
+	if total <= 0 {
+		if mean >= pass {
+			return 1
+		}
+		return 0
+	}
+	return 1 - normalCDF((pass-mean)/math.Sqrt(total))
+}
+
+func confidence(sd float64) string {
+	switch {
+	case sd <= 0.05:
+		return ConfidenceHigh
+	case sd <= 0.1:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+func normalCDF(z float64) float64 {
+	return 0.5 * (1 + math.Erf(z/math.Sqrt2))
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Min(math.Max(v, lo), hi)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
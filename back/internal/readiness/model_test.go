@@ -0,0 +1,72 @@
+package readiness
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPredict(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Input
+		want Estimate
+	}{
+		{"no evidence", Input{},
+			Estimate{Confidence: ConfidenceNone}},
+		{"mastery only", Input{Topics: []TopicEvidence{
+			{Weight: 0.6, Mastery: 80, Attempts: 95},
+			{Weight: 0.4, Mastery: 70, Attempts: 45},
+		}},
+			Estimate{PassProbability: 93.1, Lower: 50.9, Upper: 100, ExpectedScore: 74.3, Confidence: ConfidenceHigh, Attempts: 140}},
+		{"exams only", Input{Exams: []ExamEvidence{{Score: 75, Questions: 110}}},
+			Estimate{PassProbability: 93.6, Lower: 50, Upper: 100, ExpectedScore: 75, Confidence: ConfidenceMedium, ExamsConsidered: 1}},
+		{"exam on the pass mark", Input{Exams: []ExamEvidence{{Score: 65, Questions: 110}}},
+			Estimate{PassProbability: 50, Lower: 1.2, Upper: 99.5, ExpectedScore: 65, Confidence: ConfidenceMedium, ExamsConsidered: 1}},
+		{"mastery and exams around the pass mark", Input{
+			Topics: []TopicEvidence{{Weight: 1, Mastery: 65, Attempts: 200}},
+			Exams:  []ExamEvidence{{Score: 66, Questions: 110}, {Score: 64, Questions: 110}},
+		},
+			Estimate{PassProbability: 49.4, Lower: 5.1, Upper: 96.1, ExpectedScore: 64.9, Confidence: ConfidenceHigh, Attempts: 200, ExamsConsidered: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.PassMark, tt.in.ExamQuestions = 65, 110
+			tt.want.PassMark = 65
+			if got := Predict(tt.in); got != tt.want {
+				t.Errorf("Predict = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDifficultyAdjustment(t *testing.T) {
+	// The sums run in map order, so a neutral result can be off by rounding
+	neutral := func(f float64) bool { return math.Abs(f-1) < 1e-9 }
+	mix := map[string]float64{"easy": 0.3, "medium": 0.5, "hard": 0.2}
+
+	tests := []struct {
+		name     string
+		evidence map[string]DifficultyEvidence
+		check    func(float64) bool
+	}{
+		{"no attempts", nil, neutral},
+		{"easy questions only", map[string]DifficultyEvidence{
+			"easy": {Attempts: 50, Credit: 45},
+		}, neutral},
+		{"better on easy than on hard", map[string]DifficultyEvidence{
+			"easy": {Attempts: 80, Credit: 72},
+			"hard": {Attempts: 20, Credit: 8},
+		}, func(f float64) bool { return f < 1 && f >= 1-maxDifficultyAdjustment }},
+		{"mostly hard questions", map[string]DifficultyEvidence{
+			"easy": {Attempts: 10, Credit: 9},
+			"hard": {Attempts: 90, Credit: 45},
+		}, func(f float64) bool { return f > 1 && f <= 1+maxDifficultyAdjustment }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := difficultyAdjustment(tt.evidence, mix); !tt.check(got) {
+				t.Errorf("difficultyAdjustment = %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package readiness
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+)
+
+// recentExams is the number of full practice exams considered
+const recentExams = 5
+
+// Service loads a user's evidence and runs the model
+type Service struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewService creates a new readiness service
+func NewService(db *gorm.DB, cfg *config.Config) *Service {
+	return &Service{db: db, config: cfg}
+}
+
+// PassMark returns the configured pass mark as a percentage
+func (s *Service) PassMark() float64 {
+	return s.config.Exam.PassMark
+}
+
+// Passed reports whether score reaches the pass mark
+func (s *Service) Passed(score float64) bool {
+	return score >= s.config.Exam.PassMark
+}
+
+// Estimate returns the user's current pass probability
+func (s *Service) Estimate(ctx context.Context, userID uuid.UUID) (Estimate, error) {
+	in, err := s.load(s.db.WithContext(ctx), userID)
+	if err != nil {
+		return Estimate{}, err
+	}
+	return Predict(in), nil
+}
+
+func (s *Service) load(db *gorm.DB, userID uuid.UUID) (Input, error) {
+	in := Input{
+		Difficulty:    make(map[string]DifficultyEvidence),
+		DifficultyMix: s.config.Exam.DifficultyMix,
+		PassMark:      s.config.Exam.PassMark,
+		ExamQuestions: s.config.Exam.FullExamQuestions,
+	}
+
+	var topics []models.Topic
+	if err := db.Find(&topics).Error; err != nil {
+		return in, fmt.Errorf("failed to load topics: %w", err)
+	}
+	var masteries []models.UserTopicMastery
+	if err := db.Where("user_id = ?", userID).Find(&masteries).Error; err != nil {
+		return in, fmt.Errorf("failed to load topic mastery: %w", err)
+	}
+	byTopic := make(map[uuid.UUID]models.UserTopicMastery, len(masteries))
+	for _, m := range masteries {
+		byTopic[m.TopicID] = m
+	}
+	for _, t := range topics {
+		m := byTopic[t.ID]
+		in.Topics = append(in.Topics, TopicEvidence{
+			Weight:   t.Weight,
+			Mastery:  m.MasteryPercentage,
+			Attempts: m.QuestionsAttempted,
+		})
+	}
+
+	// Practice answers and completed test answers, by question difficulty
+	var rows []struct {
+		Difficulty string
+		Attempts   int
+		Credit     float64
+	}
+	if err := db.Raw(`
+		SELECT difficulty, SUM(attempts) AS attempts, SUM(credit) AS credit FROM (
+			SELECT q.difficulty, COUNT(*) AS attempts,
+				SUM(CASE WHEN a.is_correct THEN 1 ELSE 0 END) AS credit
+			FROM user_answers a JOIN questions q ON q.id = a.question_id
+			WHERE a.user_id = ?
+			GROUP BY q.difficulty
+			UNION ALL
+			SELECT q.difficulty, COUNT(*) AS attempts, SUM(ptq.credit) AS credit
+			FROM practice_test_questions ptq
+			JOIN practice_tests pt ON pt.id = ptq.practice_test_id
+			JOIN questions q ON q.id = ptq.question_id
+			WHERE pt.user_id = ? AND pt.status = 'completed' AND cardinality(ptq.selected_option_ids) > 0
+			GROUP BY q.difficulty
+		) d GROUP BY difficulty`, userID, userID).
+		Scan(&rows).Error; err != nil {
+		return in, fmt.Errorf("failed to load difficulty record: %w", err)
+	}
+	for _, r := range rows {
+		in.Difficulty[r.Difficulty] = DifficultyEvidence{Attempts: r.Attempts, Credit: r.Credit}
+	}
+
+	var exams []models.PracticeTest
+	if err := db.Where("user_id = ? AND test_type = ? AND status = ?", userID, "full_exam", "completed").
+		Order("completed_at DESC").
+		Limit(recentExams).
+		Find(&exams).Error; err != nil {
+		return in, fmt.Errorf("failed to load recent exams: %w", err)
+	}
+	for _, e := range exams {
+		in.Exams = append(in.Exams, ExamEvidence{Score: e.Score, Questions: e.TotalQuestions})
+	}
+
+	return in, nil
+}
@@ -0,0 +1,133 @@
+package streak
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Service records study activity against user streaks
+type Service struct {
+	db     *gorm.DB
+	config *config.Config
+	bus    *events.Bus
+	loc    *time.Location
+}
+
+// NewService creates a new streak service
+func NewService(db *gorm.DB, cfg *config.Config, bus *events.Bus) *Service {
+	return &Service{
+		db:     db,
+		config: cfg,
+		bus:    bus,
+		loc:    Location(cfg.Streak.DefaultTimezone, time.UTC),
+	}
+}
+
+// Subscribe records activity for answers, completed tests and module
+// progress published on bus
+func (s *Service) Subscribe(bus *events.Bus) {
+	record := func(ctx context.Context, e events.Event) error {
+		at := e.OccurredAt
+		// An auto-submitted test may be swept after midnight; credit the day
+		// the user actually sat it
+		if p, ok := e.Payload.(events.TestCompletedPayload); ok && p.AutoSubmitted && !p.StartedAt.IsZero() {
+			at = p.StartedAt
+		}
+		_, err := s.RecordActivity(ctx, e.UserID, at)
+		return err
+	}
+	bus.Subscribe(events.AnswerRecorded, record)
+	bus.Subscribe(events.TestCompleted, record)
+	bus.Subscribe(events.ModuleProgressed, record)
+}
+
+// Location returns the timezone used for the user's day boundaries
+func (s *Service) Location(user *models.User) *time.Location {
+	return Location(user.Timezone, s.loc)
+}
+
+// Current returns the streak to display at now. A streak that can no
+// longer be continued reads as zero even before the sweeper resets it.
+func (s *Service) Current(user *models.User, now time.Time) int {
+	if !Alive(stateOf(user), now, s.Location(user), s.config.Streak.FreezesPerMonth) {
+		return 0
+	}
+	return user.StudyStreak
+}
+
+// RecordActivity applies study at time at to the user's streak
+func (s *Service) RecordActivity(ctx context.Context, userID uuid.UUID, at time.Time) (Step, error) {
+	var step Step
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		step = Apply(stateOf(&user), at, s.Location(&user), s.config.Streak.FreezesPerMonth)
+		if step.Outcome == Unchanged {
+			return nil
+		}
+
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"study_streak":    step.State.Current,
+			"longest_streak":  step.State.Longest,
+			"last_study_date": step.State.LastStudy,
+			"freezes_used":    step.State.FreezesUsed,
+			"freeze_month":    step.State.FreezeMonth,
+		}).Error
+	})
+	if err != nil {
+		return Step{}, err
+	}
+
+	s.publish(ctx, userID, at, step)
+	return step, nil
+}
+
+// publish emits the events for step after it has been committed
+func (s *Service) publish(ctx context.Context, userID uuid.UUID, at time.Time, step Step) {
+	payload := events.StreakPayload{
+		Days:        step.State.Current,
+		Longest:     step.State.Longest,
+		FreezesUsed: step.FreezesUsed,
+	}
+
+	switch step.Outcome {
+	case Unchanged:
+		return
+	case Reset:
+		s.bus.Publish(ctx, events.Event{
+			Type:       events.StreakBroken,
+			UserID:     userID,
+			OccurredAt: at,
+			Payload:    events.StreakPayload{Days: step.Previous, Longest: step.State.Longest},
+		})
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: events.StreakExtended, UserID: userID, OccurredAt: at, Payload: payload})
+	for _, m := range s.config.Streak.Milestones {
+		if step.State.Current == m {
+			s.bus.Publish(ctx, events.Event{Type: events.StreakMilestone, UserID: userID, OccurredAt: at, Payload: payload})
+			break
+		}
+	}
+}
+
+func stateOf(user *models.User) State {
+	return State{
+		Current:     user.StudyStreak,
+		Longest:     user.LongestStreak,
+		LastStudy:   user.LastStudyDate,
+		FreezesUsed: user.FreezesUsed,
+		FreezeMonth: user.FreezeMonth,
+	}
+}
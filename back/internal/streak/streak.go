@@ -0,0 +1,136 @@
+// Package streak tracks consecutive days of study.
+//
+// Days are counted in the user's own timezone, so studying at 11pm in
+// Vancouver and 7am the next morning extends the streak even though both
+// fall on the same UTC day. Each calendar month a user may miss a
+// configurable number of days ("freezes") without losing the streak.
+package streak
+
+import (
+	"time"
+	// Embedded zone database so day boundaries work on minimal images
+	_ "time/tzdata"
+)
+
+// Outcome classifies what an activity did to a streak
+type Outcome int
+
+const (
+	// Unchanged means the user already studied that day
+	Unchanged Outcome = iota
+	// Started means a new streak began
+	Started
+	// Extended means the streak grew by a day
+	Extended
+	// Reset means the previous streak lapsed and a new one began
+	Reset
+)
+
+// State is the streak bookkeeping stored on the user
+type State struct {
+	Current     int
+	Longest     int
+	LastStudy   *time.Time
+	FreezesUsed int
+	FreezeMonth string
+}
+
+// Step is the result of applying one activity to a State
+type Step struct {
+	State       State
+	Outcome     Outcome
+	Previous    int // streak length before the activity
+	FreezesUsed int // freezes consumed by this activity
+}
+
+// Apply records study at time at in loc. freezesPerMonth is the number of
+// missed days forgiven per calendar month.
+func Apply(s State, at time.Time, loc *time.Location, freezesPerMonth int) Step {
+	step := Step{State: s, Previous: s.Current}
+	today := day(at, loc)
+
+	next := &step.State
+	month := today.Format("2006-01")
+	if next.FreezeMonth != month {
+		next.FreezeMonth = month
+		next.FreezesUsed = 0
+	}
+
+	if s.LastStudy == nil || s.Current == 0 {
+		next.Current = 1
+		step.Outcome = Started
+	} else {
+		gap := daysBetween(day(*s.LastStudy, loc), today)
+		switch {
+		case gap <= 0:
+			// Same day, or a late-arriving event for an earlier day
+			step.Outcome = Unchanged
+			return step
+		case gap == 1:
+			next.Current++
+			step.Outcome = Extended
+		default:
+			missed := gap - 1
+			if missed <= freezesPerMonth-next.FreezesUsed {
+				next.FreezesUsed += missed
+				step.FreezesUsed = missed
+				next.Current++
+				step.Outcome = Extended
+			} else {
+				next.Current = 1
+				step.Outcome = Reset
+			}
+		}
+	}
+
+	if next.Current > next.Longest {
+		next.Longest = next.Current
+	}
+	last := at
+	next.LastStudy = &last
+	return step
+}
+
+// Alive reports whether a streak can still be continued at now: the user
+// studied today or yesterday, or the missed days fit in the freezes left.
+func Alive(s State, now time.Time, loc *time.Location, freezesPerMonth int) bool {
+	if s.LastStudy == nil || s.Current == 0 {
+		return false
+	}
+
+	today := day(now, loc)
+	gap := daysBetween(day(*s.LastStudy, loc), today)
+	if gap <= 1 {
+		return true
+	}
+
+	used := s.FreezesUsed
+	if s.FreezeMonth != today.Format("2006-01") {
+		used = 0
+	}
+	// Days missed so far; today can still be studied
+	return gap-1 <= freezesPerMonth-used
+}
+
+// Location resolves an IANA timezone name, falling back to def
+func Location(name string, def *time.Location) *time.Location {
+	if name == "" {
+		return def
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return def
+	}
+	return loc
+}
+
+// day returns local midnight of t in loc, expressed in UTC so that days
+// can be subtracted without DST skew
+func day(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
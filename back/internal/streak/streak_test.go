@@ -0,0 +1,100 @@
+package streak
+
+import (
+	"testing"
+	"time"
+)
+
+var vancouver = Location("America/Vancouver", time.UTC)
+
+// at parses a wall-clock time in Vancouver
+func at(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, vancouver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestApply(t *testing.T) {
+	last := func(value string) *time.Time {
+		v := at(t, value)
+		return &v
+	}
+
+	tests := []struct {
+		name        string
+		state       State
+		at          string
+		freezes     int
+		wantOutcome Outcome
+		wantCurrent int
+		wantLongest int
+		wantUsed    int
+		wantMonth   string
+	}{
+		{"first study", State{}, "2026-03-02 09:00", 2,
+			Started, 1, 1, 0, "2026-03"},
+		{"after local midnight on the same UTC day", State{Current: 2, Longest: 2, LastStudy: last("2026-03-01 23:00"), FreezeMonth: "2026-03"}, "2026-03-02 07:00", 2,
+			Extended, 3, 3, 0, "2026-03"},
+		{"same local day across UTC midnight", State{Current: 2, Longest: 2, LastStudy: last("2026-03-02 08:00"), FreezeMonth: "2026-03"}, "2026-03-02 16:30", 2,
+			Unchanged, 2, 2, 0, "2026-03"},
+		{"day after clocks go forward", State{Current: 4, Longest: 6, LastStudy: last("2026-03-07 23:30"), FreezeMonth: "2026-03"}, "2026-03-08 23:30", 2,
+			Extended, 5, 6, 0, "2026-03"},
+		{"day after clocks go back", State{Current: 4, Longest: 4, LastStudy: last("2026-10-31 00:30"), FreezeMonth: "2026-10"}, "2026-11-01 23:30", 0,
+			Extended, 5, 5, 0, "2026-11"},
+		{"missed day covered by a freeze", State{Current: 3, Longest: 3, LastStudy: last("2026-03-10 20:00"), FreezeMonth: "2026-03"}, "2026-03-12 10:00", 2,
+			Extended, 4, 4, 1, "2026-03"},
+		{"gap past the freezes left", State{Current: 3, Longest: 5, LastStudy: last("2026-03-10 20:00"), FreezesUsed: 1, FreezeMonth: "2026-03"}, "2026-03-13 10:00", 2,
+			Reset, 1, 5, 1, "2026-03"},
+		{"new month restores the freezes", State{Current: 3, Longest: 3, LastStudy: last("2026-03-31 20:00"), FreezesUsed: 2, FreezeMonth: "2026-03"}, "2026-04-02 10:00", 2,
+			Extended, 4, 4, 1, "2026-04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := Apply(tt.state, at(t, tt.at), vancouver, tt.freezes)
+			got := step.State
+			if step.Outcome != tt.wantOutcome || got.Current != tt.wantCurrent || got.Longest != tt.wantLongest {
+				t.Errorf("Apply = outcome %d, current %d, longest %d; want %d, %d, %d",
+					step.Outcome, got.Current, got.Longest, tt.wantOutcome, tt.wantCurrent, tt.wantLongest)
+			}
+			if got.FreezesUsed != tt.wantUsed || got.FreezeMonth != tt.wantMonth {
+				t.Errorf("freezes = %d in %s, want %d in %s", got.FreezesUsed, got.FreezeMonth, tt.wantUsed, tt.wantMonth)
+			}
+			if step.Previous != tt.state.Current {
+				t.Errorf("Previous = %d, want %d", step.Previous, tt.state.Current)
+			}
+		})
+	}
+}
+
+func TestAlive(t *testing.T) {
+	state := func(last string, used int, month string) State {
+		v := at(t, last)
+		return State{Current: 3, Longest: 3, LastStudy: &v, FreezesUsed: used, FreezeMonth: month}
+	}
+
+	tests := []struct {
+		name    string
+		state   State
+		now     string
+		freezes int
+		want    bool
+	}{
+		{"no streak", State{}, "2026-03-02 09:00", 2, false},
+		{"studied today", state("2026-03-02 08:00", 0, "2026-03"), "2026-03-02 23:00", 0, true},
+		{"studied yesterday evening", state("2026-03-01 23:30", 0, "2026-03"), "2026-03-02 16:30", 0, true},
+		{"missed a day without freezes", state("2026-03-01 09:00", 0, "2026-03"), "2026-03-03 09:00", 0, false},
+		{"missed a day with a freeze left", state("2026-03-01 09:00", 1, "2026-03"), "2026-03-03 09:00", 2, true},
+		{"missed a day with the freezes used up", state("2026-03-01 09:00", 2, "2026-03"), "2026-03-03 09:00", 2, false},
+		{"freezes used up last month", state("2026-03-31 09:00", 2, "2026-03"), "2026-04-02 09:00", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Alive(tt.state, at(t, tt.now), vancouver, tt.freezes); got != tt.want {
+				t.Errorf("Alive = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package streak
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/internal/events"
+	"github.com/nppe-pro/api/internal/jobs"
+	"github.com/nppe-pro/api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sweeper resets streaks that can no longer be continued, so users who
+// stop studying get a StreakBroken event without having to come back first
+type Sweeper struct {
+	service *Service
+}
+
+// NewSweeper creates a sweeper backed by the given streak service
+func NewSweeper(service *Service) *Sweeper {
+	return &Sweeper{service: service}
+}
+
+// Job returns the sweeper as a periodic background job
+func (s *Sweeper) Job() jobs.Job {
+	return jobs.Job{
+		Name:     "streak_sweeper",
+		Interval: s.service.config.Streak.SweepInterval,
+		Run: func(ctx context.Context) error {
+			return s.Sweep(ctx, time.Now())
+		},
+	}
+}
+
+// Sweep breaks every streak that has lapsed as of now
+func (s *Sweeper) Sweep(ctx context.Context, now time.Time) error {
+	db := s.service.db.WithContext(ctx)
+
+	// Nobody can have missed a whole local day in under 24 hours
+	var candidates []uuid.UUID
+	if err := db.Model(&models.User{}).
+		Where("study_streak > 0 AND last_study_date < ?", now.Add(-24*time.Hour)).
+		Pluck("id", &candidates).Error; err != nil {
+		return fmt.Errorf("failed to find lapsing streaks: %w", err)
+	}
+
+	broken := 0
+	for _, id := range candidates {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		ok, err := s.breakIfLapsed(ctx, id, now)
+		if err != nil {
+			return fmt.Errorf("failed to break streak for user %s: %w", id, err)
+		}
+		if ok {
+			broken++
+		}
+	}
+
+	if broken > 0 {
+		log.Printf("🔥 Streak sweeper: broke %d streaks", broken)
+	}
+	return nil
+}
+
+func (s *Sweeper) breakIfLapsed(ctx context.Context, userID uuid.UUID, now time.Time) (bool, error) {
+	var lapsed State
+	err := s.service.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		state := stateOf(&user)
+		if Alive(state, now, s.service.Location(&user), s.service.config.Streak.FreezesPerMonth) {
+			return nil
+		}
+		lapsed = state
+		return tx.Model(&user).Update("study_streak", 0).Error
+	})
+	if err != nil || lapsed.Current == 0 {
+		return false, err
+	}
+
+	s.service.bus.Publish(ctx, events.Event{
+		Type:       events.StreakBroken,
+		UserID:     userID,
+		OccurredAt: now,
+		Payload:    events.StreakPayload{Days: lapsed.Current, Longest: lapsed.Longest},
+	})
+	return true, nil
+}
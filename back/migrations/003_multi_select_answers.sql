@@ -0,0 +1,30 @@
+-- Multi-select answers: answers become sets of option IDs
+-- Run after 002_question_import_constraints.sql
+
+-- 1) multiple_choice_multi questions have several correct options. The
+-- single-choice rule is already enforced by the _q_enforce_rules trigger.
+DROP INDEX IF EXISTS uq_one_correct_when_single;
+
+-- 2) Selected options as sets
+ALTER TABLE practice_test_questions ADD COLUMN IF NOT EXISTS selected_option_ids uuid[];
+ALTER TABLE practice_test_questions ADD COLUMN IF NOT EXISTS credit double precision DEFAULT 0;
+ALTER TABLE user_answers ADD COLUMN IF NOT EXISTS selected_option_ids uuid[];
+ALTER TABLE user_answers ALTER COLUMN selected_option_id DROP NOT NULL;
+ALTER TABLE practice_tests ADD COLUMN IF NOT EXISTS scoring_mode varchar(20);
+
+-- 3) Backfill from the single-option columns
+UPDATE practice_test_questions
+SET selected_option_ids = ARRAY[answer_id]
+WHERE answer_id IS NOT NULL AND selected_option_ids IS NULL;
+
+UPDATE practice_test_questions
+SET credit = CASE WHEN is_correct THEN 1 ELSE 0 END
+WHERE is_correct IS NOT NULL;
+
+UPDATE user_answers
+SET selected_option_ids = ARRAY[selected_option_id]
+WHERE selected_option_id IS NOT NULL AND selected_option_ids IS NULL;
+
+UPDATE practice_tests
+SET scoring_mode = 'all_or_nothing'
+WHERE status = 'completed' AND scoring_mode IS NULL;
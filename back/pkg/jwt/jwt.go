@@ -1,120 +1,214 @@
-package jwt
-
-import (
-	"errors"
-	"fmt"
-	"time"
-
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
-	"github.com/nppe-pro/api/config"
-)
-
-var (
-	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
-)
-
-// Claims represents JWT claims
-type Claims struct {
-	UserID  uuid.UUID `json:"user_id"`
-	Email   string    `json:"email"`
-	IsAdmin bool      `json:"is_admin"`
-	jwt.RegisteredClaims
-}
-
-// JWTService handles JWT operations
-type JWTService struct {
-	config *config.Config
-}
-
-// NewJWTService creates a new JWT service
-func NewJWTService(cfg *config.Config) *JWTService {
-	return &JWTService{config: cfg}
-}
-
-// GenerateToken generates a new JWT access token
-func (s *JWTService) GenerateToken(userID uuid.UUID, email string, isAdmin bool) (string, error) {
-	expirationTime := time.Now().Add(s.config.JWT.Expiration)
-
-	claims := &Claims{
-		UserID:  userID,
-		Email:   email,
-		IsAdmin: isAdmin,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWT.Secret))
-}
-
-// GenerateRefreshToken generates a new JWT refresh token
-func (s *JWTService) GenerateRefreshToken(userID uuid.UUID) (string, error) {
-	expirationTime := time.Now().Add(s.config.JWT.RefreshExpiration)
-
-	claims := &jwt.RegisteredClaims{
-		Subject:   userID.String(),
-		ExpiresAt: jwt.NewNumericDate(expirationTime),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.JWT.RefreshSecret))
-}
-
-// ValidateToken validates and parses a JWT token
-func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWT.Secret), nil
-	})
-
-	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, ErrExpiredToken
-		}
-		return nil, ErrInvalidToken
-	}
-
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, ErrInvalidToken
-	}
-
-	return claims, nil
-}
-
-// ValidateRefreshToken validates a refresh token
-func (s *JWTService) ValidateRefreshToken(tokenString string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWT.RefreshSecret), nil
-	})
-
-	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			return uuid.Nil, ErrExpiredToken
-		}
-		return uuid.Nil, ErrInvalidToken
-	}
-
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
-	if !ok || !token.Valid {
-		return uuid.Nil, ErrInvalidToken
-	}
-
-	userID, err := uuid.Parse(claims.Subject)
-	if err != nil {
-		return uuid.Nil, ErrInvalidToken
-	}
-
-	return userID, nil
-}
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+func init() {
+	// Issue times carry milliseconds so they can be told apart from a
+	// revocation cut-off in the same second
+	jwt.TimePrecision = time.Millisecond
+}
+
+// Claims represents JWT claims. RegisteredClaims.ID is the token's jti and
+// SessionID the refresh token family it was issued from. Roles and
+// Permissions are the user's grants when the token was issued.
+type Claims struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Email       string    `json:"email"`
+	Roles       []string  `json:"roles,omitempty"`
+	Permissions []string  `json:"perms,omitempty"`
+	SessionID   uuid.UUID `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// HasPermission reports whether the token grants permission
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshClaims represents refresh token claims. Every refresh token has a
+// unique ID (jti) and belongs to the family started at login.
+type RefreshClaims struct {
+	FamilyID uuid.UUID `json:"fid"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user the refresh token was issued to
+func (c *RefreshClaims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// JWTService handles JWT operations. Access tokens are signed with the key
+// ring when JWT_KEYS_DIR is set, otherwise with HS256 and JWT_SECRET.
+// Refresh tokens are only ever read by this API and stay on HS256.
+type JWTService struct {
+	config *config.Config
+	keys   *KeyRing
+}
+
+// NewJWTService creates a new JWT service, loading the key ring if one is
+// configured
+func NewJWTService(cfg *config.Config) (*JWTService, error) {
+	s := &JWTService{config: cfg}
+	if cfg.JWT.KeysDir != "" {
+		retired, err := cfg.JWT.Retirements()
+		if err != nil {
+			return nil, err
+		}
+		keys, err := LoadKeyRing(cfg.JWT.KeysDir, cfg.JWT.SigningKeyID, retired, cfg.JWT.KeyGracePeriod, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT keys: %w", err)
+		}
+		s.keys = keys
+	}
+	return s, nil
+}
+
+// JWKS returns the public keys that verify access tokens. It is empty when
+// tokens are signed with a shared secret.
+func (s *JWTService) JWKS() JWKSet {
+	if s.keys == nil {
+		return JWKSet{Keys: []JWK{}}
+	}
+	return s.keys.JWKS()
+}
+
+// GenerateToken generates a new JWT access token for the given session
+func (s *JWTService) GenerateToken(userID uuid.UUID, email string, roles, permissions []string, sessionID uuid.UUID) (string, error) {
+	expirationTime := time.Now().Add(s.config.JWT.Expiration)
+
+	claims := &Claims{
+		UserID:      userID,
+		Email:       email,
+		Roles:       roles,
+		Permissions: permissions,
+		SessionID:   sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    s.config.JWT.Issuer,
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	if s.keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(s.config.JWT.Secret))
+	}
+
+	key := s.keys.Signing()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// GenerateRefreshToken generates a new JWT refresh token in the given
+// family. It returns the token and its unique ID.
+func (s *JWTService) GenerateRefreshToken(userID, familyID uuid.UUID) (string, string, error) {
+	expirationTime := time.Now().Add(s.config.JWT.RefreshExpiration)
+
+	claims := &RefreshClaims{
+		FamilyID: familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID.String(),
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.config.JWT.RefreshSecret))
+	if err != nil {
+		return "", "", err
+	}
+	return signed, claims.ID, nil
+}
+
+// ValidateToken validates and parses a JWT token
+func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.accessKey, jwt.WithIssuer(s.config.JWT.Issuer))
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// accessKey finds the key that verifies an access token. With a key ring
+// the kid header picks the key and the key fixes the algorithm, so a token
+// cannot choose a weaker one.
+func (s *JWTService) accessKey(token *jwt.Token) (interface{}, error) {
+	if s.keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.config.JWT.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// ValidateRefreshToken validates a refresh token
+func (s *JWTService) ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.config.JWT.RefreshSecret), nil
+	})
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*RefreshClaims)
+	if !ok || !token.Valid || claims.ID == "" || claims.FamilyID == uuid.Nil {
+		return nil, ErrInvalidToken
+	}
+
+	if _, err := claims.UserID(); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,207 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is one signing key in the key ring
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer // nil for keys that only verify
+	Public    crypto.PublicKey
+	RetiredAt time.Time // when the key stopped signing, zero for current and next keys
+}
+
+// KeyRing holds the key that signs new access tokens and the keys that
+// still verify tokens signed before the last rotation.
+//
+// Keys are loaded from <kid>.pem files holding a PKCS#8 private key or a
+// PKIX public key, RSA (RS256) or Ed25519 (EdDSA). Every key is published
+// and verifies tokens until it is listed as retired; a retired key is
+// accepted until gracePeriod after it stopped signing, which covers every
+// access token it signed. Keys added ahead of the next rotation are
+// therefore published before they sign anything.
+type KeyRing struct {
+	signing     *Key
+	keys        map[string]*Key
+	gracePeriod time.Duration
+}
+
+// LoadKeyRing reads every key in dir and signs with signingID. retired
+// maps the IDs of keys that no longer sign to when they stopped.
+func LoadKeyRing(dir, signingID string, retired map[string]time.Time, gracePeriod time.Duration, now time.Time) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &KeyRing{keys: make(map[string]*Key), gracePeriod: gracePeriod}
+	for _, path := range paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		key.RetiredAt = retired[key.ID]
+		ring.keys[key.ID] = key
+	}
+
+	signing, ok := ring.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found in %s", signingID, dir)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingID)
+	}
+	if !signing.RetiredAt.IsZero() {
+		return nil, fmt.Errorf("signing key %q is listed as retired", signingID)
+	}
+	ring.signing = signing
+
+	// Retired keys past their grace period no longer verify anything
+	for id, key := range ring.keys {
+		if ring.expired(key, now) {
+			log.Printf("⚠️ JWT key %s expired on %s and can be removed", id, key.RetiredAt.Add(gracePeriod).Format(time.RFC3339))
+			delete(ring.keys, id)
+		}
+	}
+
+	return ring, nil
+}
+
+// loadKey parses the PEM file at path. The file name is the key ID.
+func loadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported private key type %T", path, parsed)
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		key.Private = parsed
+		key.Public = parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		key.Public = parsed
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("%s: RSA keys must be at least 2048 bits", path)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, key.Public)
+	}
+
+	return key, nil
+}
+
+// Signing returns the key that signs new tokens
+func (r *KeyRing) Signing() *Key {
+	return r.signing
+}
+
+// Lookup returns the key with the given ID. A retired key stops verifying
+// once its grace period is over, even if the ring was loaded before that.
+func (r *KeyRing) Lookup(id string) (*Key, bool) {
+	key, ok := r.keys[id]
+	if !ok || r.expired(key, time.Now()) {
+		return nil, false
+	}
+	return key, true
+}
+
+// expired reports whether key is retired and past its grace period at now
+func (r *KeyRing) expired(key *Key, now time.Time) bool {
+	return !key.RetiredAt.IsZero() && now.After(key.RetiredAt.Add(r.gracePeriod))
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every key in the ring that still verifies
+func (r *KeyRing) JWKS() JWKSet {
+	now := time.Now()
+	set := JWKSet{Keys: make([]JWK, 0, len(r.keys))}
+	for _, key := range r.keys {
+		if r.expired(key, now) {
+			continue
+		}
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].KeyID < set.Keys[j].KeyID
+	})
+	return set
+}
@@ -0,0 +1,115 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKey stores a new Ed25519 key as <id>.pem, private or public only
+func writeKey(t *testing.T, dir, id string, private bool) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &pem.Block{Type: "PUBLIC KEY"}
+	if private {
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(priv)
+	} else {
+		block.Bytes, err = x509.MarshalPKIXPublicKey(pub)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "current", true)
+	writeKey(t, dir, "next", true)
+	writeKey(t, dir, "previous", true)
+	writeKey(t, dir, "verify-only", false)
+
+	// Lookup and JWKS check the grace period against the clock
+	now := time.Now()
+	grace := 15 * time.Minute
+
+	tests := []struct {
+		name    string
+		signing string
+		retired map[string]time.Time
+		want    []string
+		wantErr bool
+	}{
+		{"nothing retired", "current", nil, []string{"current", "next", "previous", "verify-only"}, false},
+		{"retired within the grace period", "current", map[string]time.Time{"previous": now.Add(-grace / 2)}, []string{"current", "next", "previous", "verify-only"}, false},
+		{"retired past the grace period", "current", map[string]time.Time{"previous": now.Add(-grace - time.Second)}, []string{"current", "next", "verify-only"}, false},
+		{"retired signing key", "current", map[string]time.Time{"current": now}, nil, true},
+		{"unknown signing key", "missing", nil, nil, true},
+		{"signing key without private key", "verify-only", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := LoadKeyRing(dir, tt.signing, tt.retired, grace, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadKeyRing error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if ring.Signing().ID != tt.signing {
+				t.Errorf("signing key = %s, want %s", ring.Signing().ID, tt.signing)
+			}
+			var got []string
+			for _, jwk := range ring.JWKS().Keys {
+				got = append(got, jwk.KeyID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("published keys = %v, want %v", got, tt.want)
+			}
+			for i, id := range tt.want {
+				if got[i] != id {
+					t.Errorf("published keys = %v, want %v", got, tt.want)
+					break
+				}
+				if _, ok := ring.Lookup(id); !ok {
+					t.Errorf("key %s does not verify", id)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyRingGracePeriodEndsAfterLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "current", true)
+	writeKey(t, dir, "previous", true)
+
+	// Loaded an hour ago, when the retired key was still in its grace period
+	loaded := time.Now().Add(-time.Hour)
+	grace := 15 * time.Minute
+	ring, err := LoadKeyRing(dir, "current", map[string]time.Time{"previous": loaded}, grace, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ring.Lookup("previous"); ok {
+		t.Error("retired key still verifies after its grace period")
+	}
+	if _, ok := ring.Lookup("current"); !ok {
+		t.Error("signing key does not verify")
+	}
+	if keys := ring.JWKS().Keys; len(keys) != 1 || keys[0].KeyID != "current" {
+		t.Errorf("published keys = %v, want only current", keys)
+	}
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript keeps the timestamps (ms) of the requests in the last
+// window in a sorted set. It records the request if there is room and
+// returns {allowed, requests in window, ms until the oldest one expires}.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// rateLimitResult is the outcome of counting one request
+type rateLimitResult struct {
+	allowed   bool
+	remaining int
+	reset     time.Duration // until a slot frees up
+}
+
+// RateLimiter limits requests per user, or per IP for anonymous requests,
+// with a sliding window kept in Redis. While Redis is unreachable each
+// instance falls back to counting in memory.
+type RateLimiter struct {
+	redis    *database.RedisClient
+	enabled  bool
+	memory   *memoryWindows
+	degraded atomic.Bool
+}
+
+// NewRateLimiter creates a rate limiter
+func NewRateLimiter(redis *database.RedisClient, cfg *config.Config) *RateLimiter {
+	return &RateLimiter{
+		redis:   redis,
+		enabled: cfg.RateLimit.Enabled,
+		memory:  newMemoryWindows(),
+	}
+}
+
+// Limit returns middleware allowing rule.Requests per rule.Duration under
+// the named policy. Place it after AuthMiddleware to count per user.
+func (l *RateLimiter) Limit(policy string, rule config.RateLimitRule) gin.HandlerFunc {
+	window := rule.Duration
+	policyHeader := fmt.Sprintf("%d;w=%d", rule.Requests, int(window.Seconds()))
+
+	return func(c *gin.Context) {
+		if !l.enabled {
+			c.Next()
+			return
+		}
+
+		key := "ratelimit:" + policy + ":" + rateLimitSubject(c)
+		result := l.take(c.Request.Context(), key, rule.Requests, window)
+
+		resetSeconds := int((result.reset + time.Second - 1) / time.Second)
+		c.Header("RateLimit-Policy", policyHeader)
+		c.Header("RateLimit-Limit", strconv.Itoa(rule.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+
+		if !result.allowed {
+			c.Header("Retry-After", strconv.Itoa(resetSeconds))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Rate limit exceeded, please slow down",
+				"retry_after": resetSeconds,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// take counts a request against key in Redis, or in memory when Redis
+// fails
+func (l *RateLimiter) take(ctx context.Context, key string, limit int, window time.Duration) rateLimitResult {
+	now := time.Now()
+	res, err := slidingWindowScript.Run(ctx, l.redis.Client, []string{key},
+		now.UnixMilli(), window.Milliseconds(), limit, uuid.NewString()).Int64Slice()
+	if err != nil || len(res) != 3 {
+		if l.degraded.CompareAndSwap(false, true) {
+			log.Printf("⚠️ Rate limiter falling back to in-memory counting: %v", err)
+		}
+		return l.memory.take(key, limit, window, now)
+	}
+	if l.degraded.CompareAndSwap(true, false) {
+		log.Println("✅ Rate limiter using Redis again")
+	}
+
+	return rateLimitResult{
+		allowed:   res[0] == 1,
+		remaining: max(limit-int(res[1]), 0),
+		reset:     time.Duration(res[2]) * time.Millisecond,
+	}
+}
+
+// rateLimitSubject identifies who is making the request
+func rateLimitSubject(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		if id, ok := userID.(uuid.UUID); ok {
+			return "user:" + id.String()
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// memoryWindows is the in-process sliding window used while Redis is down
+type memoryWindows struct {
+	mu      sync.Mutex
+	windows map[string]*memoryWindow
+	swept   time.Time
+}
+
+type memoryWindow struct {
+	times  []time.Time
+	length time.Duration
+}
+
+func newMemoryWindows() *memoryWindows {
+	return &memoryWindows{windows: make(map[string]*memoryWindow)}
+}
+
+func (m *memoryWindows) take(key string, limit int, window time.Duration, now time.Time) rateLimitResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop idle keys now and then so the map cannot grow without bound
+	if now.Sub(m.swept) > time.Minute {
+		for k, w := range m.windows {
+			if len(w.times) == 0 || now.Sub(w.times[len(w.times)-1]) > w.length {
+				delete(m.windows, k)
+			}
+		}
+		m.swept = now
+	}
+
+	w, ok := m.windows[key]
+	if !ok {
+		w = &memoryWindow{length: window}
+		m.windows[key] = w
+	}
+
+	times := w.times
+	cutoff := now.Add(-window)
+	start := 0
+	for start < len(times) && !times[start].After(cutoff) {
+		start++
+	}
+	times = times[start:]
+
+	allowed := len(times) < limit
+	if allowed {
+		times = append(times, now)
+	}
+	w.times = times
+
+	reset := window
+	if len(times) > 0 {
+		reset = times[0].Add(window).Sub(now)
+	}
+	return rateLimitResult{
+		allowed:   allowed,
+		remaining: max(limit-len(times), 0),
+		reset:     reset,
+	}
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nppe-pro/api/config"
+)
+
+// LocalPrefix is the URL path under which Local serves signed downloads
+const LocalPrefix = "/files/"
+
+// Local stores blobs on disk under a root directory. It serves them itself
+// through ServeHTTP, which only answers requests carrying a valid signature.
+type Local struct {
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+// NewLocal creates a store rooted at STORAGE_LOCAL_DIR
+func NewLocal(cfg *config.Config) (*Local, error) {
+	root, err := filepath.Abs(cfg.Storage.LocalDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORAGE_LOCAL_DIR: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	secret := cfg.Storage.SigningKey
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+
+	return &Local{
+		root:    root,
+		baseURL: strings.TrimRight(cfg.Storage.PublicURL, "/"),
+		secret:  []byte(secret),
+	}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers
+// never see a partial blob
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get opens the blob. The content type is derived from the key's extension.
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		return nil, nil, ErrNotFound
+	}
+
+	return f, &Object{Key: key, Size: info.Size(), ContentType: contentTypeOf(key)}, nil
+}
+
+// Delete removes the blob
+func (l *Local) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL points at LocalPrefix on STORAGE_PUBLIC_URL with an expiry and
+// an HMAC over the key and expiry
+func (l *Local) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	exp := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	q := url.Values{}
+	q.Set("expires", exp)
+	q.Set("signature", l.sign(key, exp))
+	return l.baseURL + LocalPrefix + escapePath(key) + "?" + q.Encode(), nil
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP serves a blob from a URL made by SignedURL. Mount it under
+// LocalPrefix with the prefix stripped.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	exp := r.URL.Query().Get("expires")
+	sig := r.URL.Query().Get("signature")
+
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt ||
+		!hmac.Equal([]byte(sig), []byte(l.sign(key, exp))) {
+		http.Error(w, "invalid or expired link", http.StatusForbidden)
+		return
+	}
+
+	rc, obj, err := l.Get(r.Context(), key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", obj.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(max(expiresAt-time.Now().Unix(), 0), 10))
+	if f, ok := rc.(*os.File); ok {
+		info, _ := f.Stat()
+		http.ServeContent(w, r, path.Base(key), info.ModTime(), f)
+		return
+	}
+	io.Copy(w, rc)
+}
+
+func contentTypeOf(key string) string {
+	if t := mime.TypeByExtension(path.Ext(key)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// escapePath percent-encodes each segment of a key, leaving only RFC 3986
+// unreserved characters as they are, which is also what SigV4 signs
+func escapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = sigv4Escape(p)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nppe-pro/api/config"
+)
+
+// newTestLocal returns a store holding two blobs in a temporary directory,
+// served under LocalPrefix the way the router mounts it
+func newTestLocal(t *testing.T) *Local {
+	t.Helper()
+	cfg := &config.Config{Storage: config.StorageConfig{LocalDir: t.TempDir(), SigningKey: "signing-key"}}
+	l, err := NewLocal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(LocalPrefix, http.StripPrefix(strings.TrimSuffix(LocalPrefix, "/"), l))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	l.baseURL = server.URL
+
+	for key, body := range map[string]string{
+		"avatars/ada/128.jpg": "ada's avatar",
+		"avatars/bob/128.jpg": "bob's avatar",
+	} {
+		if err := l.Put(context.Background(), key, strings.NewReader(body), int64(len(body)), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return l
+}
+
+func get(t *testing.T, link string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestLocalServeHTTP(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		expires    time.Duration
+		tamper     func(*url.URL)
+		wantStatus int
+		wantBody   string
+	}{
+		{"signed link", time.Hour, nil, http.StatusOK, "ada's avatar"},
+		{"expired link", -time.Second, nil, http.StatusForbidden, ""},
+		{"another key", time.Hour, func(u *url.URL) {
+			u.Path = strings.Replace(u.Path, "ada", "bob", 1)
+		}, http.StatusForbidden, ""},
+		{"extended expiry", -time.Second, func(u *url.URL) {
+			q := u.Query()
+			q.Set("expires", "9999999999")
+			u.RawQuery = q.Encode()
+		}, http.StatusForbidden, ""},
+		{"tampered signature", time.Hour, func(u *url.URL) {
+			q := u.Query()
+			q.Set("signature", strings.ToUpper(q.Get("signature")))
+			u.RawQuery = q.Encode()
+		}, http.StatusForbidden, ""},
+		{"no signature", time.Hour, func(u *url.URL) { u.RawQuery = "" }, http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := l.SignedURL(ctx, "avatars/ada/128.jpg", tt.expires)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tamper != nil {
+				u, err := url.Parse(link)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tt.tamper(u)
+				link = u.String()
+			}
+
+			status, body := get(t, link)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if tt.wantBody != "" && body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLocalServeHTTPMissingBlob(t *testing.T) {
+	l := newTestLocal(t)
+	ctx := context.Background()
+
+	link, err := l.SignedURL(ctx, "avatars/ada/128.jpg", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete(ctx, "avatars/ada/128.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := get(t, link); status != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", status, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,69 @@
+// Package storage keeps uploaded files behind a small blob interface with a
+// local filesystem and an S3-compatible implementation. Files are never
+// public; clients get time-limited signed URLs.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/nppe-pro/api/config"
+)
+
+// ErrNotFound is returned when a key does not exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidKey is returned for keys that are empty, absolute or climb out
+// of the store with ".."
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Object describes a stored blob
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+}
+
+// Store holds blobs under slash-separated keys such as
+// "avatars/<user>/<id>/128.jpg"
+type Store interface {
+	// Put stores size bytes from r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob under key. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete removes the blob under key. Deleting a missing key is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads the blob until it expires
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// New creates the store selected by STORAGE_DRIVER
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.Storage.Driver {
+	case "local":
+		return NewLocal(cfg)
+	case "s3":
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// CleanKey validates a key and returns it in canonical form
+func CleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return path.Clean(key), nil
+}
@@ -0,0 +1,175 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSessionNotFound is returned for sessions that do not exist or belong
+// to another user
+var ErrSessionNotFound = errors.New("session not found")
+
+// Client identifies where a login or refresh came from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Session is a signed-in device, i.e. one refresh token family
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// Sessions lists the user's active sessions, most recently used first
+func (s *Store) Sessions(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	members, err := s.redis.Client.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(members))
+	pipe := s.redis.Client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, len(members))
+	for _, m := range members {
+		id, err := uuid.Parse(m)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		cmds = append(cmds, pipe.HGetAll(ctx, familyKey(id)))
+	}
+	if len(cmds) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	var expired []interface{}
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 || fields["user_id"] != userID.String() {
+			// The family expired; forget it
+			expired = append(expired, ids[i].String())
+			continue
+		}
+		sessions = append(sessions, sessionFrom(ids[i], fields))
+	}
+	if len(expired) > 0 {
+		if err := s.redis.Client.SRem(ctx, userFamiliesKey(userID), expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Session returns one of the user's sessions. Its CreatedAt is when the
+// user signed in; refreshing tokens does not move it.
+func (s *Store) Session(ctx context.Context, userID, sessionID uuid.UUID) (*Session, error) {
+	fields, err := s.redis.Client.HGetAll(ctx, familyKey(sessionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 || fields["user_id"] != userID.String() {
+		return nil, ErrSessionNotFound
+	}
+	session := sessionFrom(sessionID, fields)
+	return &session, nil
+}
+
+// RevokeSession signs the user out of one session. Access tokens issued
+// from it are rejected from then on.
+func (s *Store) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	owner, err := s.redis.Client.HGet(ctx, familyKey(sessionID), "user_id").Result()
+	if errors.Is(err, redis.Nil) || (err == nil && owner != userID.String()) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return s.RevokeFamily(ctx, userID, sessionID)
+}
+
+func sessionFrom(id uuid.UUID, fields map[string]string) Session {
+	return Session{
+		ID:         id,
+		Device:     describeDevice(fields["user_agent"]),
+		UserAgent:  fields["user_agent"],
+		IP:         fields["ip"],
+		CreatedAt:  unixField(fields["created_at"]),
+		LastSeenAt: unixField(fields["last_seen_at"]),
+	}
+}
+
+func unixField(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// describeDevice turns a user agent into a short label such as
+// "Chrome on Windows"
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox/", "Firefox"},
+		{"crios/", "Chrome"},
+		{"chrome/", "Chrome"},
+		{"safari/", "Safari"},
+		{"okhttp", "Android app"},
+		{"cfnetwork", "iOS app"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"iphone", "iPhone"},
+		{"ipad", "iPad"},
+		{"android", "Android"},
+		{"windows", "Windows"},
+		{"mac os x", "macOS"},
+		{"cros", "ChromeOS"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,220 @@
+// Package tokens keeps the server-side state that makes JWTs revocable.
+//
+// Every login starts a refresh token family. The family records the one
+// refresh token ID (jti) that may be used next; presenting it rotates the
+// family to a new jti, and presenting any older token of the family is
+// treated as theft and revokes the whole family. Access tokens are
+// stateless but can be denylisted by jti until they expire, and all tokens
+// issued to a user before a cut-off can be rejected at once.
+//
+// A family is also the user's session on one device: it records the client
+// it was started from and when it was last refreshed, and revoking it
+// rejects the access tokens issued from it too.
+package tokens
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nppe-pro/api/config"
+	"github.com/nppe-pro/api/pkg/database"
+	"github.com/nppe-pro/api/pkg/jwt"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrRevoked is returned for tokens that were revoked server-side
+	ErrRevoked = errors.New("token has been revoked")
+	// ErrReused is returned when a refresh token is presented twice. The
+	// family has been revoked by the time it is returned.
+	ErrReused = errors.New("refresh token reuse detected")
+)
+
+// rotateScript atomically moves a family from one jti to the next and
+// records the client that refreshed it. Returns 1 on success, 0 if the
+// family does not exist and -1 if the presented jti is not the current one.
+var rotateScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'jti')
+if not current then return 0 end
+if current ~= ARGV[1] then return -1 end
+redis.call('HSET', KEYS[1], 'jti', ARGV[2], 'ip', ARGV[4], 'user_agent', ARGV[5], 'last_seen_at', ARGV[6])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// Store keeps refresh token families and revocations in Redis
+type Store struct {
+	redis  *database.RedisClient
+	config *config.Config
+}
+
+// NewStore creates a new token store
+func NewStore(redis *database.RedisClient, cfg *config.Config) *Store {
+	return &Store{redis: redis, config: cfg}
+}
+
+// StartFamily records a new refresh token family whose first token is jti
+func (s *Store) StartFamily(ctx context.Context, userID, familyID uuid.UUID, jti string, client Client) error {
+	ttl := s.config.JWT.RefreshExpiration
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	pipe := s.redis.Client.TxPipeline()
+	pipe.HSet(ctx, familyKey(familyID),
+		"user_id", userID.String(),
+		"jti", jti,
+		"ip", client.IP,
+		"user_agent", client.UserAgent,
+		"created_at", now,
+		"last_seen_at", now,
+	)
+	pipe.Expire(ctx, familyKey(familyID), ttl)
+	pipe.SAdd(ctx, userFamiliesKey(userID), familyID.String())
+	pipe.Expire(ctx, userFamiliesKey(userID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Rotate consumes the refresh token described by claims and makes nextJTI
+// the only usable token of its family. Reuse of an already rotated token
+// revokes the family and returns ErrReused.
+func (s *Store) Rotate(ctx context.Context, claims *jwt.RefreshClaims, nextJTI string, client Client) error {
+	userID, err := claims.UserID()
+	if err != nil {
+		return ErrRevoked
+	}
+	if err := s.checkCutoff(ctx, userID, claims.IssuedAt.Time); err != nil {
+		return err
+	}
+
+	ttl := s.config.JWT.RefreshExpiration.Milliseconds()
+	res, err := rotateScript.Run(ctx, s.redis.Client, []string{familyKey(claims.FamilyID)},
+		claims.ID, nextJTI, ttl, client.IP, client.UserAgent, time.Now().Unix()).Int()
+	if err != nil {
+		return err
+	}
+
+	switch res {
+	case 1:
+		return nil
+	case -1:
+		if err := s.RevokeFamily(ctx, userID, claims.FamilyID); err != nil {
+			return err
+		}
+		return ErrReused
+	default:
+		return ErrRevoked
+	}
+}
+
+// RevokeFamily revokes every refresh token of a family
+func (s *Store) RevokeFamily(ctx context.Context, userID, familyID uuid.UUID) error {
+	pipe := s.redis.Client.TxPipeline()
+	pipe.Del(ctx, familyKey(familyID))
+	pipe.SRem(ctx, userFamiliesKey(userID), familyID.String())
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeAll revokes every refresh token family of the user and rejects
+// access tokens issued to them up to now
+func (s *Store) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	families, err := s.redis.Client.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(families)+1)
+	for _, f := range families {
+		if id, err := uuid.Parse(f); err == nil {
+			keys = append(keys, familyKey(id))
+		}
+	}
+	keys = append(keys, userFamiliesKey(userID))
+
+	cutoff := time.Now().UnixMilli()
+	pipe := s.redis.Client.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.Set(ctx, cutoffKey(userID), cutoff, s.config.JWT.RefreshExpiration)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Deny denylists an access token until it expires
+func (s *Store) Deny(ctx context.Context, claims *jwt.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.redis.Set(ctx, denyKey(claims.ID), 1, ttl)
+}
+
+// Check returns ErrRevoked if the access token was denylisted, its session
+// was revoked, or it was issued before the user's last "log out everywhere"
+func (s *Store) Check(ctx context.Context, claims *jwt.Claims) error {
+	pipe := s.redis.Client.Pipeline()
+	denied := pipe.Exists(ctx, denyKey(claims.ID))
+	session := pipe.Exists(ctx, familyKey(claims.SessionID))
+	cutoff := pipe.Get(ctx, cutoffKey(claims.UserID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	if claims.ID != "" && denied.Val() > 0 {
+		return ErrRevoked
+	}
+	if claims.SessionID != uuid.Nil && session.Val() == 0 {
+		return ErrRevoked
+	}
+
+	var issued time.Time
+	if claims.IssuedAt != nil {
+		issued = claims.IssuedAt.Time
+	}
+	return cutoffError(cutoff.Val(), issued)
+}
+
+func (s *Store) checkCutoff(ctx context.Context, userID uuid.UUID, issued time.Time) error {
+	value, err := s.redis.Get(ctx, cutoffKey(userID))
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return cutoffError(value, issued)
+}
+
+// cutoffError returns ErrRevoked if issued is not after the stored cut-off.
+// Both are in milliseconds, so a token issued right after a "log out
+// everywhere" is not caught by it.
+func cutoffError(value string, issued time.Time) error {
+	cutoff, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	if issued.UnixMilli() <= cutoff {
+		return ErrRevoked
+	}
+	return nil
+}
+
+func familyKey(familyID uuid.UUID) string {
+	return "refresh_family:" + familyID.String()
+}
+
+func userFamiliesKey(userID uuid.UUID) string {
+	return "user_refresh_families:" + userID.String()
+}
+
+func cutoffKey(userID uuid.UUID) string {
+	return "tokens_revoked_before_ms:" + userID.String()
+}
+
+func denyKey(jti string) string {
+	return "access_denylist:" + jti
+}
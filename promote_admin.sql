@@ -1,4 +1,11 @@
--- Promote user to admin and verified
+-- Promote user to super admin and mark them verified
+-- Roles are seeded by running the API with -migrate
 UPDATE users 
-SET is_verified = true, is_admin = true 
-WHERE email = 'admin@nppepro.local';
\ No newline at end of file
+SET is_verified = true 
+WHERE email = 'admin@nppepro.local';
+
+INSERT INTO user_roles (user_id, role_id, created_at)
+SELECT u.id, r.id, NOW()
+FROM users u JOIN roles r ON r.name = 'super_admin'
+WHERE u.email = 'admin@nppepro.local'
+ON CONFLICT DO NOTHING;